@@ -0,0 +1,274 @@
+package yahoofinanceapi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// BreakerPolicy configures the circuit breaker kept per endpoint family.
+type BreakerPolicy struct {
+	// FailureRatio opens the breaker once this share of the calls in the
+	// current window failed.
+	FailureRatio float64
+	// MinRequests is how many calls a window needs before FailureRatio
+	// is looked at.
+	MinRequests int
+	// Window is how long failures are counted before the counters reset.
+	Window time.Duration
+	// Cooldown is how long the breaker stays open before letting probe
+	// calls through.
+	Cooldown time.Duration
+	// HalfOpenRequests is how many probe calls may run at once while the
+	// breaker is half-open.
+	HalfOpenRequests int
+}
+
+// DefaultBreakerPolicy opens after half of at least 10 calls within a
+// minute failed and probes again after 30 seconds.
+var DefaultBreakerPolicy = BreakerPolicy{
+	FailureRatio:     0.5,
+	MinRequests:      10,
+	Window:           time.Minute,
+	Cooldown:         30 * time.Second,
+	HalfOpenRequests: 1,
+}
+
+// WithCircuitBreaker puts a circuit breaker in front of every endpoint
+// family. Connection errors, 429 and 5xx responses count as failures.
+// Zero fields of p are taken from DefaultBreakerPolicy.
+func WithCircuitBreaker(p BreakerPolicy) ClientOption {
+	return func(c *Client) {
+		if p.FailureRatio <= 0 {
+			p.FailureRatio = DefaultBreakerPolicy.FailureRatio
+		}
+		if p.MinRequests <= 0 {
+			p.MinRequests = DefaultBreakerPolicy.MinRequests
+		}
+		if p.Window <= 0 {
+			p.Window = DefaultBreakerPolicy.Window
+		}
+		if p.Cooldown <= 0 {
+			p.Cooldown = DefaultBreakerPolicy.Cooldown
+		}
+		if p.HalfOpenRequests <= 0 {
+			p.HalfOpenRequests = DefaultBreakerPolicy.HalfOpenRequests
+		}
+		c.breakerPolicy = &p
+	}
+}
+
+// BreakerState is the state of a circuit breaker.
+type BreakerState int
+
+const (
+	// BreakerClosed lets every call through.
+	BreakerClosed BreakerState = iota
+	// BreakerOpen refuses every call with ErrCircuitOpen.
+	BreakerOpen
+	// BreakerHalfOpen lets a few probe calls through to see whether the
+	// endpoint recovered.
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	}
+	return fmt.Sprintf("BreakerState(%d)", int(s))
+}
+
+// BreakerStatus describes the breaker of one endpoint family, as
+// returned by Breakers.
+type BreakerStatus struct {
+	State BreakerState
+	// Requests and Failures are counted over the current window.
+	Requests int
+	Failures int
+	// OpenedAt is when the breaker last opened.
+	OpenedAt time.Time
+}
+
+// CircuitOpenError is returned instead of calling an endpoint family
+// whose breaker is open. It matches ErrCircuitOpen.
+type CircuitOpenError struct {
+	Endpoint Endpoint
+	// RetryAt is when the breaker lets the next probe through.
+	RetryAt time.Time
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("yahoo finance: circuit breaker open for %s until %s", e.Endpoint, e.RetryAt.Format(time.RFC3339))
+}
+
+func (e *CircuitOpenError) Is(target error) bool {
+	return target == ErrCircuitOpen
+}
+
+// Breakers reports the state of the breaker of every endpoint family
+// that was called so far. It is empty unless WithCircuitBreaker is used.
+func (c *Client) Breakers() map[Endpoint]BreakerStatus {
+	c.breakersMu.Lock()
+	defer c.breakersMu.Unlock()
+	out := make(map[Endpoint]BreakerStatus, len(c.breakers))
+	for e, b := range c.breakers {
+		out[e] = b.status(time.Now())
+	}
+	return out
+}
+
+// breaker returns the breaker of e, creating it on first use. It
+// returns nil when no breaker is configured.
+func (c *Client) breaker(e Endpoint) *circuitBreaker {
+	if c.breakerPolicy == nil {
+		return nil
+	}
+	c.breakersMu.Lock()
+	defer c.breakersMu.Unlock()
+	if b, ok := c.breakers[e]; ok {
+		return b
+	}
+	if c.breakers == nil {
+		c.breakers = make(map[Endpoint]*circuitBreaker)
+	}
+	b := &circuitBreaker{endpoint: e, policy: *c.breakerPolicy, windowStart: time.Now()}
+	c.breakers[e] = b
+	return b
+}
+
+// recordBreaker counts the outcome of an attempt allowed by b in
+// generation gen. Attempts cut short by ctx are not counted.
+func (c *Client) recordBreaker(ctx context.Context, b *circuitBreaker, gen uint64, resp *http.Response, err error) {
+	if b == nil {
+		return
+	}
+	if ctx.Err() != nil {
+		b.release(gen)
+		return
+	}
+	failed := err != nil || resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	state, changed := b.record(gen, failed, time.Now())
+	if !changed {
+		return
+	}
+	if state == BreakerOpen {
+		c.log().WarnContext(ctx, "Yahoo Finance circuit breaker opened", "endpoint", b.endpoint, "cooldown", b.policy.Cooldown)
+	} else {
+		c.log().InfoContext(ctx, "Yahoo Finance circuit breaker closed", "endpoint", b.endpoint)
+	}
+}
+
+type circuitBreaker struct {
+	endpoint Endpoint
+	policy   BreakerPolicy
+
+	mu          sync.Mutex
+	state       BreakerState
+	generation  uint64
+	windowStart time.Time
+	requests    int
+	failures    int
+	openedAt    time.Time
+	probes      int
+}
+
+// allow reports whether a call may go through. The returned generation
+// has to be handed back to record.
+func (b *circuitBreaker) allow(now time.Time) (uint64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.advance(now)
+	switch b.state {
+	case BreakerOpen:
+		return 0, &CircuitOpenError{Endpoint: b.endpoint, RetryAt: b.openedAt.Add(b.policy.Cooldown)}
+	case BreakerHalfOpen:
+		if b.probes >= b.policy.HalfOpenRequests {
+			return 0, &CircuitOpenError{Endpoint: b.endpoint, RetryAt: now}
+		}
+		b.probes++
+	}
+	return b.generation, nil
+}
+
+// record counts the outcome of a call allowed in generation gen and
+// returns the new state when it changed.
+func (b *circuitBreaker) record(gen uint64, failed bool, now time.Time) (BreakerState, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if gen != b.generation {
+		return b.state, false
+	}
+	switch b.state {
+	case BreakerHalfOpen:
+		b.probes--
+		if failed {
+			b.trip(now)
+		} else {
+			b.reset(BreakerClosed, now)
+		}
+		return b.state, true
+	case BreakerClosed:
+		b.advance(now)
+		b.requests++
+		if failed {
+			b.failures++
+		}
+		if b.requests >= b.policy.MinRequests && float64(b.failures) >= b.policy.FailureRatio*float64(b.requests) {
+			b.trip(now)
+			return b.state, true
+		}
+	}
+	return b.state, false
+}
+
+// release gives back a half-open probe slot without counting the call.
+func (b *circuitBreaker) release(gen uint64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if gen == b.generation && b.state == BreakerHalfOpen {
+		b.probes--
+	}
+}
+
+// advance starts a new window or moves an open breaker to half-open once
+// its time has come. b.mu must be held.
+func (b *circuitBreaker) advance(now time.Time) {
+	switch b.state {
+	case BreakerClosed:
+		if now.Sub(b.windowStart) >= b.policy.Window {
+			b.windowStart, b.requests, b.failures = now, 0, 0
+		}
+	case BreakerOpen:
+		if !now.Before(b.openedAt.Add(b.policy.Cooldown)) {
+			b.reset(BreakerHalfOpen, now)
+		}
+	}
+}
+
+// trip opens the breaker. b.mu must be held.
+func (b *circuitBreaker) trip(now time.Time) {
+	b.reset(BreakerOpen, now)
+	b.openedAt = now
+}
+
+// reset moves to state with fresh counters; calls allowed before are no
+// longer counted. b.mu must be held.
+func (b *circuitBreaker) reset(state BreakerState, now time.Time) {
+	b.state = state
+	b.generation++
+	b.windowStart, b.requests, b.failures, b.probes = now, 0, 0, 0
+}
+
+func (b *circuitBreaker) status(now time.Time) BreakerStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.advance(now)
+	return BreakerStatus{State: b.state, Requests: b.requests, Failures: b.failures, OpenedAt: b.openedAt}
+}
@@ -0,0 +1,100 @@
+package yahoofinanceapi_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	yahoofinanceapi "github.com/oscarli916/yahoo-finance-api"
+	"github.com/oscarli916/yahoo-finance-api/yahoofinancetest"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	server := yahoofinancetest.NewServer()
+	defer server.Close()
+	server.SetQuote(yahoofinanceapi.StockQuote{Symbol: "AAPL", RegularMarketPrice: 190})
+	client := server.NewClient(
+		yahoofinanceapi.WithRetryPolicy(yahoofinanceapi.RetryPolicy{MaxAttempts: 1}),
+		yahoofinanceapi.WithLogger(nil),
+		yahoofinanceapi.WithCircuitBreaker(yahoofinanceapi.BreakerPolicy{MinRequests: 4, Cooldown: 50 * time.Millisecond}),
+	)
+	quote := yahoofinanceapi.NewQuoteWithClient(client)
+
+	server.Fail(yahoofinancetest.FailServerError, -1)
+	for i := 0; i < 4; i++ {
+		if _, err := quote.GetQuote("AAPL"); err == nil {
+			t.Fatal("GetQuote succeeded against a failing server")
+		}
+	}
+	var open *yahoofinanceapi.CircuitOpenError
+	if _, err := quote.GetQuote("AAPL"); !errors.As(err, &open) || !errors.Is(err, yahoofinanceapi.ErrCircuitOpen) {
+		t.Fatalf("GetQuote error = %v, want a *CircuitOpenError", err)
+	}
+	if state := client.Breakers()[yahoofinanceapi.EndpointQuote].State; state != yahoofinanceapi.BreakerOpen {
+		t.Fatalf("breaker state = %v, want open", state)
+	}
+
+	// The first probe fails and opens the breaker again.
+	time.Sleep(60 * time.Millisecond)
+	if _, err := quote.GetQuote("AAPL"); errors.Is(err, yahoofinanceapi.ErrCircuitOpen) {
+		t.Fatalf("probe was refused: %v", err)
+	}
+	if state := client.Breakers()[yahoofinanceapi.EndpointQuote].State; state != yahoofinanceapi.BreakerOpen {
+		t.Fatalf("breaker state after a failed probe = %v, want open", state)
+	}
+
+	server.Recover()
+	time.Sleep(60 * time.Millisecond)
+	if _, err := quote.GetQuote("AAPL"); err != nil {
+		t.Fatal(err)
+	}
+	if state := client.Breakers()[yahoofinanceapi.EndpointQuote].State; state != yahoofinanceapi.BreakerClosed {
+		t.Errorf("breaker state after a good probe = %v, want closed", state)
+	}
+}
+
+func TestCircuitBreakerAndBudget(t *testing.T) {
+	server := yahoofinancetest.NewServer()
+	defer server.Close()
+	server.SetQuote(yahoofinanceapi.StockQuote{Symbol: "AAPL", RegularMarketPrice: 190})
+	client := server.NewClient(
+		yahoofinanceapi.WithRetryPolicy(yahoofinanceapi.RetryPolicy{MaxAttempts: 1}),
+		yahoofinanceapi.WithLogger(nil),
+		yahoofinanceapi.WithCircuitBreaker(yahoofinanceapi.BreakerPolicy{MinRequests: 4, Cooldown: 50 * time.Millisecond, HalfOpenRequests: 1}),
+		yahoofinanceapi.WithEndpointBudget(yahoofinanceapi.EndpointQuote, yahoofinanceapi.Budget{Hourly: 4}),
+	)
+	quote := yahoofinanceapi.NewQuoteWithClient(client)
+	used := func() int64 {
+		return client.Usage().Endpoints[yahoofinanceapi.EndpointQuote].Hourly.Used
+	}
+
+	server.Fail(yahoofinancetest.FailServerError, -1)
+	for i := 0; i < 4; i++ {
+		quote.GetQuote("AAPL")
+	}
+	server.Recover()
+
+	// Calls refused by the open breaker never reach Yahoo and cost
+	// nothing.
+	if _, err := quote.GetQuote("AAPL"); !errors.Is(err, yahoofinanceapi.ErrCircuitOpen) {
+		t.Fatalf("GetQuote error = %v, want ErrCircuitOpen", err)
+	}
+	if n := used(); n != 4 {
+		t.Errorf("budget used = %d, want 4", n)
+	}
+
+	// A batch call refused by the budget gives its probe slot back to
+	// the interactive call that follows.
+	time.Sleep(60 * time.Millisecond)
+	batch := yahoofinanceapi.ContextWithPriority(context.Background(), yahoofinanceapi.PriorityBatch)
+	if _, err := quote.GetQuoteContext(batch, "AAPL"); !errors.Is(err, yahoofinanceapi.ErrBudgetExhausted) {
+		t.Fatalf("batch GetQuote error = %v, want ErrBudgetExhausted", err)
+	}
+	if _, err := quote.GetQuote("AAPL"); err != nil {
+		t.Fatalf("interactive probe failed: %v", err)
+	}
+	if n := used(); n != 5 {
+		t.Errorf("budget used = %d, want 5", n)
+	}
+}
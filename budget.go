@@ -0,0 +1,231 @@
+package yahoofinanceapi
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Budget caps how many upstream requests a client makes per clock hour
+// and per UTC day. A zero limit means unlimited.
+type Budget struct {
+	Hourly int64
+	Daily  int64
+	// InteractiveReserve is the share of each limit, between 0 and 1,
+	// that batch calls may not use so it stays available to interactive
+	// calls.
+	InteractiveReserve float64
+}
+
+// WithBudget sets the request budget shared by all endpoint families.
+func WithBudget(b Budget) ClientOption {
+	return func(c *Client) {
+		c.budget.total = b
+	}
+}
+
+// WithEndpointBudget sets a request budget for a single endpoint family,
+// on top of the one set with WithBudget.
+func WithEndpointBudget(e Endpoint, b Budget) ClientOption {
+	return func(c *Client) {
+		if c.budget.endpoints == nil {
+			c.budget.endpoints = make(map[Endpoint]Budget)
+		}
+		c.budget.endpoints[e] = b
+	}
+}
+
+// Priority tells the budget how important a call is.
+type Priority int
+
+const (
+	// PriorityInteractive calls are never refused; they are counted and
+	// may use the reserve that batch calls leave free.
+	PriorityInteractive Priority = iota
+	// PriorityBatch calls fail with ErrBudgetExhausted once they would
+	// exceed the budget minus its interactive reserve.
+	PriorityBatch
+)
+
+type priorityKey struct{}
+
+// ContextWithPriority sets the priority of the calls made with the
+// returned context. Calls are interactive by default.
+func ContextWithPriority(ctx context.Context, p Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, p)
+}
+
+func priorityOf(ctx context.Context) Priority {
+	p, _ := ctx.Value(priorityKey{}).(Priority)
+	return p
+}
+
+// BudgetError is returned for a batch call that would exceed a budget.
+// It matches ErrBudgetExhausted.
+type BudgetError struct {
+	Endpoint Endpoint
+	// Window is "hourly" or "daily".
+	Window string
+	Limit  int64
+	// ResetsAt is when the exhausted window starts over.
+	ResetsAt time.Time
+}
+
+func (e *BudgetError) Error() string {
+	return fmt.Sprintf("yahoo finance: %s budget of %d requests exhausted for %s until %s", e.Window, e.Limit, e.Endpoint, e.ResetsAt.Format(time.RFC3339))
+}
+
+func (e *BudgetError) Is(target error) bool {
+	return target == ErrBudgetExhausted
+}
+
+// UsageWindow is the consumption of one budget window.
+type UsageWindow struct {
+	Used  int64
+	Limit int64
+	// Remaining is what is left of the tightest applicable limit, or -1
+	// when no limit applies.
+	Remaining int64
+	ResetsAt  time.Time
+}
+
+// EndpointUsage is the consumption of an endpoint family, or of the
+// whole client.
+type EndpointUsage struct {
+	Hourly UsageWindow
+	Daily  UsageWindow
+}
+
+// UsageReport is returned by Usage.
+type UsageReport struct {
+	Total     EndpointUsage
+	Endpoints map[Endpoint]EndpointUsage
+}
+
+// Usage reports the requests consumed and remaining in the current hour
+// and day, in total and per endpoint family. Calls answered from the
+// response cache or shared with an identical call consume nothing.
+func (c *Client) Usage() UsageReport {
+	return c.budget.report(time.Now())
+}
+
+// spendBudget accounts for one upstream request of endpoint e, refusing
+// it when it is a batch call that does not fit into the budget.
+func (c *Client) spendBudget(ctx context.Context, e Endpoint) error {
+	return c.budget.spend(e, priorityOf(ctx), time.Now())
+}
+
+// budgetTracker counts requests per hour and per day.
+type budgetTracker struct {
+	total     Budget
+	endpoints map[Endpoint]Budget
+
+	mu         sync.Mutex
+	hour, day  time.Time
+	hourly     map[Endpoint]int64
+	daily      map[Endpoint]int64
+	hourlyUsed int64
+	dailyUsed  int64
+}
+
+// roll starts new windows when the hour or the day changed. b.mu must be
+// held.
+func (b *budgetTracker) roll(now time.Time) {
+	now = now.UTC()
+	if hour := now.Truncate(time.Hour); !hour.Equal(b.hour) {
+		b.hour, b.hourly, b.hourlyUsed = hour, make(map[Endpoint]int64), 0
+	}
+	if day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC); !day.Equal(b.day) {
+		b.day, b.daily, b.dailyUsed = day, make(map[Endpoint]int64), 0
+	}
+}
+
+func (b *budgetTracker) spend(e Endpoint, p Priority, now time.Time) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.roll(now)
+	if p == PriorityBatch {
+		own := b.endpoints[e]
+		checks := []struct {
+			window string
+			budget Budget
+			used   int64
+			limit  int64
+			resets time.Time
+		}{
+			{"hourly", b.total, b.hourlyUsed, b.total.Hourly, b.hour.Add(time.Hour)},
+			{"daily", b.total, b.dailyUsed, b.total.Daily, b.day.AddDate(0, 0, 1)},
+			{"hourly", own, b.hourly[e], own.Hourly, b.hour.Add(time.Hour)},
+			{"daily", own, b.daily[e], own.Daily, b.day.AddDate(0, 0, 1)},
+		}
+		for _, check := range checks {
+			if check.limit <= 0 {
+				continue
+			}
+			batchLimit := check.limit - int64(float64(check.limit)*check.budget.InteractiveReserve)
+			if check.used >= batchLimit {
+				return &BudgetError{Endpoint: e, Window: check.window, Limit: check.limit, ResetsAt: check.resets}
+			}
+		}
+	}
+	b.hourly[e]++
+	b.daily[e]++
+	b.hourlyUsed++
+	b.dailyUsed++
+	return nil
+}
+
+func (b *budgetTracker) report(now time.Time) UsageReport {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.roll(now)
+
+	hourResets, dayResets := b.hour.Add(time.Hour), b.day.AddDate(0, 0, 1)
+	report := UsageReport{
+		Total: EndpointUsage{
+			Hourly: usageWindow(b.hourlyUsed, b.total.Hourly, remaining(b.hourlyUsed, b.total.Hourly), hourResets),
+			Daily:  usageWindow(b.dailyUsed, b.total.Daily, remaining(b.dailyUsed, b.total.Daily), dayResets),
+		},
+		Endpoints: make(map[Endpoint]EndpointUsage),
+	}
+	endpoints := make(map[Endpoint]bool)
+	for e := range b.endpoints {
+		endpoints[e] = true
+	}
+	for e := range b.daily {
+		endpoints[e] = true
+	}
+	for e := range endpoints {
+		own := b.endpoints[e]
+		report.Endpoints[e] = EndpointUsage{
+			Hourly: usageWindow(b.hourly[e], own.Hourly, tightest(remaining(b.hourly[e], own.Hourly), report.Total.Hourly.Remaining), hourResets),
+			Daily:  usageWindow(b.daily[e], own.Daily, tightest(remaining(b.daily[e], own.Daily), report.Total.Daily.Remaining), dayResets),
+		}
+	}
+	return report
+}
+
+func usageWindow(used, limit, left int64, resets time.Time) UsageWindow {
+	return UsageWindow{Used: used, Limit: limit, Remaining: left, ResetsAt: resets}
+}
+
+// remaining returns what is left of limit, or -1 without a limit.
+func remaining(used, limit int64) int64 {
+	if limit <= 0 {
+		return -1
+	}
+	if used >= limit {
+		return 0
+	}
+	return limit - used
+}
+
+// tightest returns the smaller of two remaining counts, where -1 means
+// unlimited.
+func tightest(a, b int64) int64 {
+	if a < 0 || (b >= 0 && b < a) {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,83 @@
+package yahoofinanceapi_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	yahoofinanceapi "github.com/oscarli916/yahoo-finance-api"
+	"github.com/oscarli916/yahoo-finance-api/yahoofinancetest"
+)
+
+func TestBudget(t *testing.T) {
+	server := yahoofinancetest.NewServer()
+	defer server.Close()
+	server.SetQuote(yahoofinanceapi.StockQuote{Symbol: "AAPL", RegularMarketPrice: 190})
+	client := server.NewClient(
+		yahoofinanceapi.WithEndpointBudget(yahoofinanceapi.EndpointQuote, yahoofinanceapi.Budget{Hourly: 10, Daily: 100, InteractiveReserve: 0.2}),
+		yahoofinanceapi.WithLogger(nil),
+	)
+	quote := yahoofinanceapi.NewQuoteWithClient(client)
+	batch := yahoofinanceapi.ContextWithPriority(context.Background(), yahoofinanceapi.PriorityBatch)
+
+	// Batch calls may use the budget minus its 20% interactive reserve.
+	for i := 0; i < 8; i++ {
+		if _, err := quote.GetQuoteContext(batch, "AAPL"); err != nil {
+			t.Fatalf("batch call %d: %v", i+1, err)
+		}
+	}
+	_, err := quote.GetQuoteContext(batch, "AAPL")
+	var budgetErr *yahoofinanceapi.BudgetError
+	if !errors.As(err, &budgetErr) || !errors.Is(err, yahoofinanceapi.ErrBudgetExhausted) {
+		t.Fatalf("batch call 9 error = %v, want a *BudgetError", err)
+	}
+	if budgetErr.Endpoint != yahoofinanceapi.EndpointQuote || budgetErr.Window != "hourly" || budgetErr.Limit != 10 {
+		t.Errorf("BudgetError = %+v", budgetErr)
+	}
+	if want := time.Now().UTC().Truncate(time.Hour).Add(time.Hour); !budgetErr.ResetsAt.Equal(want) {
+		t.Errorf("ResetsAt = %v, want %v", budgetErr.ResetsAt, want)
+	}
+
+	// Interactive calls are never refused, even beyond the budget.
+	for i := 0; i < 3; i++ {
+		if _, err := quote.GetQuote("AAPL"); err != nil {
+			t.Fatalf("interactive call %d: %v", i+1, err)
+		}
+	}
+
+	usage := client.Usage().Endpoints[yahoofinanceapi.EndpointQuote]
+	if usage.Hourly.Used != 11 || usage.Hourly.Limit != 10 || usage.Hourly.Remaining != 0 {
+		t.Errorf("hourly usage = %+v, want 11 used of 10", usage.Hourly)
+	}
+	if usage.Daily.Used != 11 || usage.Daily.Remaining != 89 {
+		t.Errorf("daily usage = %+v, want 11 used and 89 left", usage.Daily)
+	}
+	if n := quoteRequests(server); n != 11 {
+		t.Errorf("server got %d quote requests, want 11", n)
+	}
+}
+
+func TestBudgetCountsRetries(t *testing.T) {
+	server := yahoofinancetest.NewServer()
+	defer server.Close()
+	server.SetQuote(yahoofinanceapi.StockQuote{Symbol: "AAPL", RegularMarketPrice: 190})
+	client := server.NewClient(
+		yahoofinanceapi.WithRetryPolicy(fastRetries),
+		yahoofinanceapi.WithBudget(yahoofinanceapi.Budget{Hourly: 1000}),
+		yahoofinanceapi.WithLogger(nil),
+	)
+
+	server.Fail(yahoofinancetest.FailServerError, 2)
+	if _, err := yahoofinanceapi.NewQuoteWithClient(client).GetQuote("AAPL"); err != nil {
+		t.Fatal(err)
+	}
+	usage := client.Usage()
+	if n := usage.Endpoints[yahoofinanceapi.EndpointQuote].Hourly.Used; n != 3 {
+		t.Errorf("quote requests charged = %d, want 3", n)
+	}
+	// The cookie and crumb requests count towards the total.
+	if n := usage.Total.Hourly.Used; n != 5 {
+		t.Errorf("total requests charged = %d, want 5", n)
+	}
+}
@@ -0,0 +1,281 @@
+package yahoofinanceapi
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CachedResponse is a successful response kept by a ResponseCache.
+type CachedResponse struct {
+	StatusCode int         `json:"status"`
+	Header     http.Header `json:"header"`
+	Body       []byte      `json:"body"`
+	Expires    time.Time   `json:"expires"`
+}
+
+// ResponseCache stores API responses between calls. Get returns nil and
+// no error on a miss. Implementations must be safe for concurrent use.
+type ResponseCache interface {
+	Get(ctx context.Context, key string) (*CachedResponse, error)
+	Set(ctx context.Context, key string, r *CachedResponse) error
+}
+
+// CacheTTL is how long responses stay cached per kind of data. A zero
+// duration disables caching for that kind.
+type CacheTTL struct {
+	Quote time.Duration
+	// IntradayChart applies to charts with a minute or hour interval.
+	IntradayChart time.Duration
+	// DailyChart applies to charts with a daily or longer interval.
+	DailyChart time.Duration
+	Options    time.Duration
+}
+
+// DefaultCacheTTL keeps quotes only for a few seconds and daily bars for
+// an hour.
+var DefaultCacheTTL = CacheTTL{
+	Quote:         5 * time.Second,
+	IntradayChart: time.Minute,
+	DailyChart:    time.Hour,
+	Options:       time.Minute,
+}
+
+// WithResponseCache makes the client answer repeated chart, quote and
+// options calls from cache for the durations in ttl.
+func WithResponseCache(cache ResponseCache, ttl CacheTTL) ClientOption {
+	return func(c *Client) {
+		c.cache = cache
+		c.cacheTTL = ttl
+	}
+}
+
+type cacheBypassKey struct{}
+
+// ContextWithCacheBypass makes calls made with the returned context skip
+// the response cache. Their fresh responses still replace the cached ones.
+func ContextWithCacheBypass(ctx context.Context) context.Context {
+	return context.WithValue(ctx, cacheBypassKey{}, true)
+}
+
+func cacheBypassed(ctx context.Context) bool {
+	bypass, _ := ctx.Value(cacheBypassKey{}).(bool)
+	return bypass
+}
+
+// ttlFor returns how long a response of endpoint e requested with params
+// may be cached.
+func (c *Client) ttlFor(e Endpoint, params url.Values) time.Duration {
+	switch e {
+	case EndpointQuote:
+		return c.cacheTTL.Quote
+	case EndpointOptions:
+		return c.cacheTTL.Options
+	case EndpointChart:
+		if interval := params.Get("interval"); strings.HasSuffix(interval, "m") || strings.HasSuffix(interval, "h") {
+			return c.cacheTTL.IntradayChart
+		}
+		return c.cacheTTL.DailyChart
+	}
+	return 0
+}
+
+// cacheKey identifies a call by endpoint path and sorted parameters. The
+// host and the crumb are left out, and period2, which defaults to the
+// current time, is rounded down to ttl so that such calls share an entry.
+func cacheKey(rawURL string, params url.Values, ttl time.Duration) string {
+	path := rawURL
+	if u, err := url.Parse(rawURL); err == nil {
+		path = u.EscapedPath()
+	}
+	query := make(url.Values, len(params))
+	for k, v := range params {
+		query[k] = v
+	}
+	query.Del("crumb")
+	if end, err := strconv.ParseInt(query.Get("period2"), 10, 64); err == nil && ttl >= time.Second {
+		step := int64(ttl / time.Second)
+		query.Set("period2", strconv.FormatInt(end-end%step, 10))
+	}
+	return path + "?" + query.Encode()
+}
+
+// cachedResponse looks up key and turns a fresh entry into a response.
+func (c *Client) cachedResponse(ctx context.Context, key string) *http.Response {
+	entry, err := c.cache.Get(ctx, key)
+	if err != nil {
+		c.log().WarnContext(ctx, "Failed to read Yahoo Finance response cache", "err", err)
+		return nil
+	}
+	if entry == nil || !time.Now().Before(entry.Expires) {
+		return nil
+	}
+	header := entry.Header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	return &http.Response{
+		Status:        strconv.Itoa(entry.StatusCode) + " " + http.StatusText(entry.StatusCode),
+		StatusCode:    entry.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          &bufferedBody{Reader: bytes.NewReader(entry.Body), data: entry.Body},
+		ContentLength: int64(len(entry.Body)),
+	}
+}
+
+// storeResponse caches the buffered body of resp under key for ttl.
+func (c *Client) storeResponse(ctx context.Context, key string, resp *http.Response, ttl time.Duration) {
+	entry := &CachedResponse{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header.Clone(),
+		Body:       bodyBytes(resp),
+		Expires:    time.Now().Add(ttl),
+	}
+	entry.Header.Del("Set-Cookie")
+	if err := c.cache.Set(ctx, key, entry); err != nil {
+		c.log().WarnContext(ctx, "Failed to write Yahoo Finance response cache", "err", err)
+	}
+}
+
+// MemoryCache is an in-memory ResponseCache that evicts the least
+// recently used entry once it holds maxEntries responses.
+type MemoryCache struct {
+	maxEntries int
+
+	mu      sync.Mutex
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+type memoryCacheEntry struct {
+	key      string
+	response *CachedResponse
+}
+
+// NewMemoryCache returns a MemoryCache holding up to maxEntries
+// responses, or an unbounded one if maxEntries is not positive.
+func NewMemoryCache(maxEntries int) *MemoryCache {
+	return &MemoryCache{
+		maxEntries: maxEntries,
+		order:      list.New(),
+		entries:    make(map[string]*list.Element),
+	}
+}
+
+func (m *MemoryCache) Get(ctx context.Context, key string) (*CachedResponse, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	elem, ok := m.entries[key]
+	if !ok {
+		return nil, nil
+	}
+	entry := elem.Value.(*memoryCacheEntry)
+	if !time.Now().Before(entry.response.Expires) {
+		m.order.Remove(elem)
+		delete(m.entries, key)
+		return nil, nil
+	}
+	m.order.MoveToFront(elem)
+	return entry.response, nil
+}
+
+func (m *MemoryCache) Set(ctx context.Context, key string, r *CachedResponse) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if elem, ok := m.entries[key]; ok {
+		elem.Value.(*memoryCacheEntry).response = r
+		m.order.MoveToFront(elem)
+		return nil
+	}
+	m.entries[key] = m.order.PushFront(&memoryCacheEntry{key: key, response: r})
+	for m.maxEntries > 0 && m.order.Len() > m.maxEntries {
+		oldest := m.order.Back()
+		m.order.Remove(oldest)
+		delete(m.entries, oldest.Value.(*memoryCacheEntry).key)
+	}
+	return nil
+}
+
+// Len reports how many responses the cache holds, expired ones included.
+func (m *MemoryCache) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.order.Len()
+}
+
+// FileCache is a ResponseCache keeping one JSON file per response in a
+// directory, so that cached data is shared between processes.
+type FileCache struct {
+	dir string
+}
+
+// NewFileCache returns a FileCache storing its files in dir, which is
+// created on the first Set.
+func NewFileCache(dir string) *FileCache {
+	return &FileCache{dir: dir}
+}
+
+func (f *FileCache) Get(ctx context.Context, key string) (*CachedResponse, error) {
+	path := f.path(key)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var r CachedResponse
+	if err := json.Unmarshal(data, &r); err != nil {
+		os.Remove(path)
+		return nil, nil
+	}
+	if !time.Now().Before(r.Expires) {
+		os.Remove(path)
+		return nil, nil
+	}
+	return &r, nil
+}
+
+func (f *FileCache) Set(ctx context.Context, key string, r *CachedResponse) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(f.dir, 0o700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(f.dir, "tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), f.path(key))
+}
+
+// path maps key to a file name that is safe on every file system.
+func (f *FileCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(f.dir, hex.EncodeToString(sum[:])+".json")
+}
@@ -0,0 +1,99 @@
+package yahoofinanceapi_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	yahoofinanceapi "github.com/oscarli916/yahoo-finance-api"
+	"github.com/oscarli916/yahoo-finance-api/yahoofinancetest"
+)
+
+func TestMemoryCache(t *testing.T) {
+	server := yahoofinancetest.NewServer()
+	defer server.Close()
+	server.SetQuote(yahoofinanceapi.StockQuote{Symbol: "AAPL", RegularMarketPrice: 190})
+	cache := yahoofinanceapi.NewMemoryCache(10)
+	client := server.NewClient(yahoofinanceapi.WithResponseCache(cache, yahoofinanceapi.DefaultCacheTTL))
+	quote := yahoofinanceapi.NewQuoteWithClient(client)
+
+	for i := 0; i < 3; i++ {
+		if q, err := quote.GetQuote("AAPL"); err != nil || q.RegularMarketPrice != 190 {
+			t.Fatalf("GetQuote = %v, %v", q.RegularMarketPrice, err)
+		}
+	}
+	if n := quoteRequests(server); n != 1 {
+		t.Errorf("server got %d quote requests, want 1", n)
+	}
+	if n := client.Usage().Endpoints[yahoofinanceapi.EndpointQuote].Hourly.Used; n != 1 {
+		t.Errorf("budget used = %d, want cache hits to be free", n)
+	}
+
+	// A bypassing call goes upstream and refreshes the entry.
+	server.SetQuote(yahoofinanceapi.StockQuote{Symbol: "AAPL", RegularMarketPrice: 191})
+	q, err := quote.GetQuoteContext(yahoofinanceapi.ContextWithCacheBypass(context.Background()), "AAPL")
+	if err != nil || q.RegularMarketPrice != 191 {
+		t.Fatalf("bypassing GetQuote = %v, %v, want 191", q.RegularMarketPrice, err)
+	}
+	if q, err := quote.GetQuote("AAPL"); err != nil || q.RegularMarketPrice != 191 {
+		t.Errorf("GetQuote after the bypass = %v, %v, want 191", q.RegularMarketPrice, err)
+	}
+	if n := quoteRequests(server); n != 2 {
+		t.Errorf("server got %d quote requests, want 2", n)
+	}
+	if n := cache.Len(); n != 1 {
+		t.Errorf("cache holds %d entries, want 1", n)
+	}
+}
+
+func TestCacheExpires(t *testing.T) {
+	server := yahoofinancetest.NewServer()
+	defer server.Close()
+	server.SetQuote(yahoofinanceapi.StockQuote{Symbol: "AAPL", RegularMarketPrice: 190})
+	ttl := yahoofinanceapi.CacheTTL{Quote: 20 * time.Millisecond}
+	quote := yahoofinanceapi.NewQuoteWithClient(server.NewClient(yahoofinanceapi.WithResponseCache(yahoofinanceapi.NewMemoryCache(10), ttl)))
+
+	quote.GetQuote("AAPL")
+	time.Sleep(30 * time.Millisecond)
+	quote.GetQuote("AAPL")
+	if n := quoteRequests(server); n != 2 {
+		t.Errorf("server got %d quote requests, want 2", n)
+	}
+}
+
+func TestCacheSkipsErrors(t *testing.T) {
+	server := yahoofinancetest.NewServer()
+	defer server.Close()
+	server.SetQuote(yahoofinanceapi.StockQuote{Symbol: "AAPL", RegularMarketPrice: 190})
+	client := server.NewClient(
+		yahoofinanceapi.WithResponseCache(yahoofinanceapi.NewMemoryCache(10), yahoofinanceapi.DefaultCacheTTL),
+		yahoofinanceapi.WithRetryPolicy(yahoofinanceapi.RetryPolicy{MaxAttempts: 1}),
+		yahoofinanceapi.WithLogger(nil),
+	)
+	quote := yahoofinanceapi.NewQuoteWithClient(client)
+
+	server.Fail(yahoofinancetest.FailServerError, 1)
+	if _, err := quote.GetQuote("AAPL"); err == nil {
+		t.Fatal("GetQuote succeeded against a failing server")
+	}
+	if _, err := quote.GetQuote("AAPL"); err != nil {
+		t.Fatalf("failed response was cached: %v", err)
+	}
+}
+
+func TestFileCacheSurvivesRestart(t *testing.T) {
+	server := yahoofinancetest.NewServer()
+	defer server.Close()
+	server.SetQuote(yahoofinanceapi.StockQuote{Symbol: "AAPL", RegularMarketPrice: 190})
+	dir := t.TempDir()
+
+	for i := 0; i < 2; i++ {
+		client := server.NewClient(yahoofinanceapi.WithResponseCache(yahoofinanceapi.NewFileCache(dir), yahoofinanceapi.DefaultCacheTTL))
+		if q, err := yahoofinanceapi.NewQuoteWithClient(client).GetQuote("AAPL"); err != nil || q.RegularMarketPrice != 190 {
+			t.Fatalf("GetQuote = %v, %v", q.RegularMarketPrice, err)
+		}
+	}
+	if n := quoteRequests(server); n != 1 {
+		t.Errorf("server got %d quote requests, want 1", n)
+	}
+}
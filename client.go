@@ -1,127 +1,569 @@
 package yahoofinanceapi
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
-	"math/rand"
 	"net/http"
 	"net/url"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // RotateThreshold defines how many requests are allowed
-// before we force-refresh cookies and crumb.
+// before we force-refresh cookies and crumb, unless the client
+// was given another RotationPolicy.
 const RotateThreshold int64 = 1000
 
 type Client struct {
 	client    *http.Client
-	cookies   []*http.Cookie
-	crumb     string
-	callCount int64
-	mu        sync.Mutex
+	baseURL   string
+	cookieURL string
+	crumbURL  string
+	headers   http.Header
+	retry     RetryPolicy
+	rotation  RotationPolicy
+	logger    *slog.Logger
+
+	proxyPool *ProxyPool
+	routes    []*route
+	nextRoute atomic.Uint64
+
+	failover   HostFailover
+	hosts      []*host
+	hostsMu    sync.Mutex
+	activeHost int
+
+	headerProfiles   []HeaderProfile
+	maxResponseBytes int64
+
+	sessionStore  SessionStore
+	sessionMaxAge time.Duration
+
+	forcedRefreshes atomic.Int64
+
+	cache    ResponseCache
+	cacheTTL CacheTTL
+
+	budget budgetTracker
+
+	breakerPolicy *BreakerPolicy
+	breakers      map[Endpoint]*circuitBreaker
+	breakersMu    sync.Mutex
+
+	observers []Observer
+
+	coalesce  bool
+	coalesced atomic.Int64
+	flights   map[string]*flight
+	flightsMu sync.Mutex
+
+	beforeRotate func(RotationEvent)
+	afterRotate  func(RotationEvent)
+
+	defaultLimit   *RateLimit
+	endpointLimits map[Endpoint]RateLimit
+	limiters       map[Endpoint]*tokenBucket
+	limitersMu     sync.Mutex
+}
+
+// ClientOption configures a Client built by NewClient.
+type ClientOption func(*Client)
+
+// WithHTTPClient makes the Client send every request through hc.
+func WithHTTPClient(hc *http.Client) ClientOption {
+	return func(c *Client) {
+		if hc != nil {
+			c.client = hc
+		}
+	}
+}
+
+// WithTransport replaces the RoundTripper of the underlying http.Client.
+func WithTransport(rt http.RoundTripper) ClientOption {
+	return func(c *Client) {
+		hc := *c.client
+		hc.Transport = rt
+		c.client = &hc
+	}
+}
+
+// WithTimeout sets the overall timeout of a single HTTP request.
+func WithTimeout(d time.Duration) ClientOption {
+	return func(c *Client) {
+		hc := *c.client
+		hc.Timeout = d
+		c.client = &hc
+	}
+}
+
+// WithBaseURL points the finance endpoints (chart, quote, options, crumb)
+// at baseURL instead of BASE_URL.
+func WithBaseURL(baseURL string) ClientOption {
+	return func(c *Client) {
+		c.baseURL = strings.TrimRight(baseURL, "/")
+	}
+}
+
+// WithCookieURL sets the endpoint used to obtain the session cookies.
+func WithCookieURL(cookieURL string) ClientOption {
+	return func(c *Client) {
+		c.cookieURL = cookieURL
+	}
+}
+
+// WithCrumbURL sets the endpoint used to obtain the crumb. By default it is
+// derived from the base URL.
+func WithCrumbURL(crumbURL string) ClientOption {
+	return func(c *Client) {
+		c.crumbURL = crumbURL
+	}
+}
+
+// WithHeaders adds headers to every request, overriding the header
+// profile of the session.
+func WithHeaders(h http.Header) ClientOption {
+	return func(c *Client) {
+		c.headers = h.Clone()
+	}
+}
+
+// WithLogger sends all library output to l, so it carries the caller's
+// attributes and handler. Passing nil silences the library.
+func WithLogger(l *slog.Logger) ClientOption {
+	return func(c *Client) {
+		if l == nil {
+			l = slog.New(discardHandler{})
+		}
+		c.logger = l
+	}
+}
+
+// WithLogHandler is a shorthand for WithLogger(slog.New(h)).
+func WithLogHandler(h slog.Handler) ClientOption {
+	if h == nil {
+		return WithLogger(nil)
+	}
+	return WithLogger(slog.New(h))
+}
+
+// NewClient returns an isolated Client with its own cookies and crumb.
+func NewClient(opts ...ClientOption) *Client {
+	c := &Client{
+		client:    &http.Client{},
+		baseURL:   BASE_URL,
+		cookieURL: COOKIE_URL,
+		retry:     DefaultRetryPolicy,
+		rotation:  DefaultRotationPolicy,
+
+		headerProfiles:   DefaultHeaderProfiles,
+		maxResponseBytes: DefaultMaxResponseBytes,
+		sessionMaxAge:    DefaultSessionMaxAge,
+		coalesce:         true,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.crumbURL == "" {
+		c.crumbURL = c.baseURL + "/v1/test/getcrumb"
+	}
+	c.setupHosts()
+	c.setupRoutes()
+	return c
 }
 
 var instance *Client
 var once sync.Once
 
+// GetClient returns the package-wide Client shared by NewHistory,
+// NewQuote, NewOption and NewTicker.
 func GetClient() *Client {
 	once.Do(func() {
-		instance = &Client{client: &http.Client{}}
+		instance = NewClient()
 	})
 	return instance
 }
 
+// log returns the logger set by WithLogger, or slog.Default().
+func (c *Client) log() *slog.Logger {
+	if c.logger != nil {
+		return c.logger
+	}
+	return slog.Default()
+}
+
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+func (h discardHandler) WithAttrs([]slog.Attr) slog.Handler      { return h }
+func (h discardHandler) WithGroup(string) slog.Handler           { return h }
+
+// BaseURL returns the base URL the finance endpoints are built on.
+func (c *Client) BaseURL() string {
+	return c.baseURL
+}
+
 // Get is the public entry. It automatically rotates session
-// every RotateThreshold calls to avoid Yahoo 429 limits.
+// according to the client's RotationPolicy (by default every
+// RotateThreshold calls) to avoid Yahoo 429 limits.
 func (c *Client) Get(url string, params url.Values) (*http.Response, error) {
-	c.maybeRotateSession()
-	c.getCrumb()
-	return c.get(url, params)
+	return c.GetContext(context.Background(), url, params)
+}
+
+// GetContext is like Get but carries ctx into the session bootstrap
+// and the request itself, so cancelling ctx aborts the call.
+//
+// Network errors and 429/502/503/504 responses are retried according to
+// the client's RetryPolicy. A 429 received while no crumb could be
+// obtained is treated as a broken session, which is dropped and
+// bootstrapped again before the next attempt. A 401 rejecting the crumb
+// or cookie forces one session refresh and replays the request without
+// counting it as a retry.
+//
+// With WithCircuitBreaker, calls to an endpoint family whose breaker is
+// open fail at once with a *CircuitOpenError. Every attempt the breaker
+// lets through is charged to the request budget, and batch calls (see
+// ContextWithPriority) that would exceed it fail with a *BudgetError.
+//
+// Any other non-2xx response is returned as an *APIError, and a 2xx
+// response that is not what the endpoint should return (an HTML consent
+// or error page, a non-JSON body) fails with ErrConsentRequired or
+// ErrUnexpectedContent. Bodies are read into memory up to the client's
+// size limit before GetContext returns.
+//
+// With a ResponseCache configured, chart, quote and options calls are
+// answered from cache while their entry is fresh, unless ctx was made
+// with ContextWithCacheBypass. Identical calls made concurrently share a
+// single request unless coalescing was turned off with WithCoalescing.
+//
+// Every call is reported to the observers added with WithObserver, and
+// every HTTP request it sends to those that implement RequestObserver.
+func (c *Client) GetContext(ctx context.Context, url string, params url.Values) (*http.Response, error) {
+	return c.observe(ctx, url, params, func(ctx context.Context, result *CallResult) (*http.Response, error) {
+		return c.fetchShared(ctx, url, params, result)
+	})
+}
+
+// fetchCached answers a call from the response cache or fetches it and
+// caches the result.
+func (c *Client) fetchCached(ctx context.Context, url string, params url.Values, result *CallResult) (*http.Response, error) {
+	if c.cache == nil {
+		return c.fetch(ctx, url, params, result)
+	}
+	ttl := c.ttlFor(c.endpointOf(url), params)
+	if ttl <= 0 {
+		return c.fetch(ctx, url, params, result)
+	}
+	key := cacheKey(url, params, ttl)
+	if !cacheBypassed(ctx) {
+		if resp := c.cachedResponse(ctx, key); resp != nil {
+			result.CacheHit = true
+			return resp, nil
+		}
+	}
+	resp, err := c.fetch(ctx, url, params, result)
+	if err == nil {
+		c.storeResponse(ctx, key, resp, ttl)
+	}
+	return resp, err
+}
+
+// fetch performs a call with retries, session refreshes and failover,
+// and fills in result as it goes.
+func (c *Client) fetch(ctx context.Context, url string, params url.Values, result *CallResult) (*http.Response, error) {
+	e := c.endpointOf(url)
+	br := c.breaker(e)
+	replayed := false
+	for attempt := 1; ; {
+		var gen uint64
+		if br != nil {
+			var err error
+			if gen, err = br.allow(time.Now()); err != nil {
+				return nil, err
+			}
+		}
+		// The budget is charged only for requests the breaker lets
+		// through; a refused one gives its half-open probe slot back.
+		if err := c.spendBudget(ctx, e); err != nil {
+			if br != nil {
+				br.release(gen)
+			}
+			return nil, err
+		}
+		r := c.pickRoute()
+		if c.maybeRotateSession(ctx, r, attempt == 1 && !replayed) {
+			result.SessionRefreshed = true
+		}
+		s, err := r.sessions.get(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				c.recordBreaker(ctx, br, gen, nil, err)
+				return nil, ctx.Err()
+			}
+			c.log().ErrorContext(ctx, "Failed to bootstrap Yahoo Finance session", "err", err)
+		}
+
+		resp, err := c.get(withAttempt(ctx, attempt), r, url, params, s)
+		result.Retries = attempt - 1
+		result.Route = r.name
+		if s != nil {
+			result.SessionID = s.id
+		}
+		if resp != nil && resp.Request != nil {
+			result.Host = resp.Request.URL.Host
+		}
+		c.reportRoute(ctx, r, resp, err)
+		if c.trackStreaks(ctx, r, s, resp, err) {
+			result.SessionRefreshed = true
+		}
+		c.recordBreaker(ctx, br, gen, resp, err)
+		if err == nil && !replayed && isSessionRejected(resp) {
+			resp.Body.Close()
+			replayed = true
+			c.forceRefresh(ctx, r, s)
+			result.SessionRefreshed = true
+			continue
+		}
+		if err == nil && !isRetryableStatus(resp.StatusCode) {
+			if resp.StatusCode < 200 || resp.StatusCode > 299 {
+				return nil, c.newAPIError(url, resp)
+			}
+			if err := checkContent(e, resp); err != nil {
+				return nil, err
+			}
+			return resp, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if errors.Is(err, ErrResponseTooLarge) {
+			return nil, err
+		}
+
+		var wait time.Duration
+		if err == nil {
+			err = c.newAPIError(url, resp)
+			if d, ok := retryAfter(resp); ok {
+				if c.retry.MaxBackoff > 0 && d > c.retry.MaxBackoff {
+					return nil, err
+				}
+				wait = d
+			}
+			if resp.StatusCode == http.StatusTooManyRequests && (s == nil || s.crumb == "") {
+				if r.sessions.invalidate(s) {
+					event := refreshEvent(r, s, RotationRateLimited)
+					r.resetCounters()
+					c.clearStoredSession(ctx, r)
+					c.sessionRefreshed(ctx, event)
+					result.SessionRefreshed = true
+				}
+			}
+		}
+		if attempt >= c.retry.MaxAttempts {
+			return nil, err
+		}
+		if wait == 0 {
+			wait = c.retry.backoff(attempt)
+		}
+		c.log().WarnContext(ctx, "retrying Yahoo Finance request", "url", url, "attempt", attempt, "wait", wait, "err", err)
+		if err := sleep(ctx, wait); err != nil {
+			return nil, err
+		}
+		attempt++
+	}
 }
 
-// maybeRotateSession increments the counter and clears
-// cookie / crumb once the threshold is reached.
-func (c *Client) maybeRotateSession() {
-	c.mu.Lock()
-	c.callCount++
-	if c.callCount%RotateThreshold == 0 {
-		slog.Info("rotating Yahoo Finance session", "calls", c.callCount)
-		c.cookies = nil
-		c.crumb = ""
+// ForcedRefreshes reports how many times a session was discarded because
+// Yahoo rejected its crumb or cookie.
+func (c *Client) ForcedRefreshes() int64 {
+	return c.forcedRefreshes.Load()
+}
+
+// forceRefresh drops s so that the next call bootstraps a new session.
+func (c *Client) forceRefresh(ctx context.Context, r *route, s *session) {
+	if r.sessions.invalidate(s) {
+		event := refreshEvent(r, s, RotationRejected)
+		r.resetCounters()
+		c.clearStoredSession(ctx, r)
+		c.forcedRefreshes.Add(1)
+		c.sessionRefreshed(ctx, event)
+		c.log().WarnContext(ctx, "Yahoo Finance rejected session, refreshing cookie and crumb")
 	}
-	c.mu.Unlock()
 }
 
-func (c *Client) get(url string, params url.Values) (*http.Response, error) {
-	if c.crumb != "" {
-		params.Add("crumb", c.crumb)
+// get sends a single GET request through r carrying the cookies and
+// crumb of s, which may be nil. Endpoints built from the base URL are
+// sent to the active host.
+func (c *Client) get(ctx context.Context, r *route, endpoint string, params url.Values, s *session) (*http.Response, error) {
+	h := c.pickHost(ctx, r.client)
+	req, err := c.newRequest(ctx, http.MethodGet, c.rewrite(h, endpoint), params, nil, s)
+	if err != nil {
+		return nil, err
 	}
-	url = fmt.Sprintf("%s?%s", url, params.Encode())
+	resp, err := c.send(ctx, r, s, r.client, c.endpointOf(endpoint), req)
+	c.reportHost(ctx, h, resp, err)
+	return resp, err
+}
 
-	req, err := http.NewRequest("GET", url, nil)
+// newRequest builds a request with realistic browser headers and the
+// cookies and crumb of s, which may be nil.
+func (c *Client) newRequest(ctx context.Context, method, endpoint string, params url.Values, body io.Reader, s *session) (*http.Request, error) {
+	query := make(url.Values, len(params)+1)
+	for k, v := range params {
+		query[k] = v
+	}
+	if s != nil && s.crumb != "" {
+		query.Set("crumb", s.crumb)
+	}
+	target := endpoint
+	if len(query) > 0 {
+		target = fmt.Sprintf("%s?%s", endpoint, query.Encode())
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
 	if err != nil {
-		slog.Error("Failed to create request", "err", err)
+		c.log().ErrorContext(ctx, "Failed to create request", "err", err)
 		return nil, err
 	}
 
 	// attach cookies
-	for _, cookie := range c.cookies {
-		req.AddCookie(cookie)
+	if s != nil {
+		for _, cookie := range s.cookies {
+			req.AddCookie(cookie)
+		}
 	}
 
 	// realistic browser headers
-	req.Header.Set("User-Agent", USER_AGENTS[rand.Intn(len(USER_AGENTS))])
-	req.Header.Set("Accept-Language", "en-US,en;q=0.9,ko;q=0.8")
+	c.requestProfile(ctx, s).apply(req.Header)
 	req.Header.Set("Accept", "application/json, text/plain, */*")
 	req.Header.Set("Referer", "https://finance.yahoo.com/")
 	req.Header.Set("Connection", "keep-alive")
+	for name, values := range c.headers {
+		req.Header[name] = values
+	}
+	return req, nil
+}
 
-	resp, err := c.client.Do(req)
+// send waits for the rate limiter of e, performs req with hc and buffers
+// the response body. The request is reported to the request observers
+// along with r and s, the route and session it was sent for, which may be
+// nil.
+func (c *Client) send(ctx context.Context, r *route, s *session, hc *http.Client, e Endpoint, req *http.Request) (*http.Response, error) {
+	if err := c.waitRateLimit(ctx, e); err != nil {
+		return nil, err
+	}
+	// API calls are charged to the budget by fetch. Session bootstrap
+	// requests are counted here and never refused.
+	if e == EndpointCookie || e == EndpointCrumb {
+		c.budget.spend(e, PriorityInteractive, time.Now())
+	}
+
+	start := time.Now()
+	resp, err := hc.Do(req)
 	if err != nil {
-		slog.Error("Failed to get data from Yahoo Finance API", "err", err)
+		c.log().ErrorContext(ctx, "Failed to get data from Yahoo Finance API", "err", err)
+		c.requestSent(ctx, r, s, e, req, start, nil, err)
 		return nil, err
 	}
+	if err := c.bufferBody(resp); err != nil {
+		c.log().ErrorContext(ctx, "Failed to read Yahoo Finance API response", "err", err)
+		c.requestSent(ctx, r, s, e, req, start, resp, err)
+		return nil, err
+	}
+	c.requestSent(ctx, r, s, e, req, start, resp, nil)
 
 	return resp, nil
 }
 
-// getCookie fetches fresh cookies if none are cached.
-func (c *Client) getCookie() {
-	if len(c.cookies) > 0 {
-		return
+// bootstrapSession reuses the stored session if there is a usable one and
+// otherwise obtains fresh cookies and a crumb. It is only called by the
+// session manager of r, which serializes concurrent refreshes.
+func (c *Client) bootstrapSession(ctx context.Context, r *route) (*session, error) {
+	if s := c.loadStoredSession(ctx, r); s != nil {
+		c.log().DebugContext(ctx, "reusing stored Yahoo Finance session", "session", s.id)
+		return s, nil
 	}
 
-	endpoint := "https://fc.yahoo.com"
-	resp, err := c.get(endpoint, url.Values{})
+	ctx = withoutHeaderOverride(ctx)
+	s := &session{id: newSessionID(), profile: c.pickHeaderProfile(), created: time.Now()}
+	cookies, err := c.getCookie(ctx, r, s)
 	if err != nil {
-		slog.Error("Failed to get cookie", "err", err)
-		return
+		return nil, err
 	}
-
-	c.cookies = resp.Cookies()
+	s.cookies = cookies
+	crumb, err := c.getCrumb(ctx, r, s)
+	if err != nil {
+		return nil, err
+	}
+	s.crumb = crumb
+	c.saveSession(ctx, r, s)
+	return s, nil
 }
 
-// getCrumb fetches crumb lazily.
-func (c *Client) getCrumb() {
-	if c.crumb != "" {
-		return
+// getCookie fetches the session cookies. Redirects are followed with a
+// throwaway cookie jar, and when Yahoo answers with its EU consent page
+// the consent form is submitted so that the A1/A3 cookies get issued.
+//
+// s is the session being bootstrapped; only its header profile is used.
+func (c *Client) getCookie(ctx context.Context, r *route, s *session) ([]*http.Cookie, error) {
+	jar, err := newSessionJar()
+	if err != nil {
+		return nil, err
 	}
+	hc := *r.client
+	hc.Jar = jar
 
-	c.getCookie()
-	endpoint := fmt.Sprintf("%s/v1/test/getcrumb", BASE_URL)
-	resp, err := c.get(endpoint, url.Values{})
+	req, err := c.newRequest(ctx, http.MethodGet, c.cookieURL, nil, nil, s)
 	if err != nil {
-		slog.Error("Failed to get crumb", "err", err)
-		return
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,*/*;q=0.8")
+	resp, err := c.send(ctx, r, s, &hc, EndpointCookie, req)
+	if err != nil {
+		c.log().ErrorContext(ctx, "Failed to get cookie", "err", err)
+		return nil, err
+	}
+
+	if form := findConsentForm(resp); form != nil {
+		c.log().DebugContext(ctx, "submitting Yahoo consent form", "action", form.action.String())
+		if err := c.submitConsent(ctx, r, &hc, form, s); err != nil {
+			c.log().ErrorContext(ctx, "Failed to accept Yahoo consent", "err", err)
+			return nil, err
+		}
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	urls := []string{c.cookieURL, c.baseURL, c.crumbURL}
+	for _, h := range c.hosts {
+		urls = append(urls, h.url)
+	}
+	return collectCookies(jar, urls...), nil
+}
+
+// getCrumb fetches the crumb that belongs to the cookies of s. Anything
+// that does not look like a crumb is rejected rather than cached.
+func (c *Client) getCrumb(ctx context.Context, r *route, s *session) (string, error) {
+	resp, err := c.get(ctx, r, c.crumbURL, url.Values{}, s)
 	if err != nil {
-		slog.Error("Error reading response body", "err", err)
-		return
+		c.log().ErrorContext(ctx, "Failed to get crumb", "err", err)
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", c.newAPIError(c.crumbURL, resp)
+	}
+	if err := checkContent(EndpointCrumb, resp); err != nil {
+		return "", err
 	}
 
-	c.crumb = string(body)
+	crumb := strings.TrimSpace(string(bodyBytes(resp)))
+	if !validCrumb(crumb) {
+		if len(crumb) > 32 {
+			crumb = crumb[:32] + "..."
+		}
+		return "", fmt.Errorf("%w: %q", ErrInvalidCrumb, crumb)
+	}
+	return crumb, nil
 }
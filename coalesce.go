@@ -0,0 +1,108 @@
+package yahoofinanceapi
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// WithCoalescing turns the merging of identical concurrent calls on or
+// off. It is on by default.
+func WithCoalescing(enabled bool) ClientOption {
+	return func(c *Client) {
+		c.coalesce = enabled
+	}
+}
+
+// flight is a call whose result is shared by every caller that asked for
+// the same thing while it was running.
+type flight struct {
+	done   chan struct{}
+	resp   *http.Response
+	err    error
+	result CallResult
+}
+
+// fetchShared runs fetchCached once for identical concurrent calls. Every
+// caller gets its own copy of the response. When the caller that made
+// the request gives up because its ctx ended, the others try again
+// instead of inheriting its cancellation. Calls of different priorities
+// are not merged, so that each one is checked against the budget.
+func (c *Client) fetchShared(ctx context.Context, url string, params url.Values, result *CallResult) (*http.Response, error) {
+	if !c.coalesce {
+		return c.fetchCached(ctx, url, params, result)
+	}
+	key := fmt.Sprintf("%s%s#p%d", cacheKey(url, params, 0), headerOverrideOf(ctx), priorityOf(ctx))
+	if cacheBypassed(ctx) {
+		key += "#bypass"
+	}
+	for {
+		c.flightsMu.Lock()
+		if f, ok := c.flights[key]; ok {
+			c.flightsMu.Unlock()
+			select {
+			case <-f.done:
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+			if f.err != nil && (errors.Is(f.err, context.Canceled) || errors.Is(f.err, context.DeadlineExceeded)) {
+				continue
+			}
+			c.coalesced.Add(1)
+			*result = f.result
+			result.Coalesced = true
+			return copyResponse(f.resp), f.err
+		}
+		f := &flight{done: make(chan struct{})}
+		if c.flights == nil {
+			c.flights = make(map[string]*flight)
+		}
+		c.flights[key] = f
+		c.flightsMu.Unlock()
+
+		f.resp, f.err = c.fetchCached(ctx, url, params, result)
+		f.result = *result
+
+		c.flightsMu.Lock()
+		delete(c.flights, key)
+		c.flightsMu.Unlock()
+		close(f.done)
+		return copyResponse(f.resp), f.err
+	}
+}
+
+// Coalesced reports how many calls were answered by sharing the response
+// of an identical call that was already in flight.
+func (c *Client) Coalesced() int64 {
+	return c.coalesced.Load()
+}
+
+// copyResponse returns a response with its own header and body reader
+// over the buffered body of resp.
+func copyResponse(resp *http.Response) *http.Response {
+	if resp == nil {
+		return nil
+	}
+	data := bodyBytes(resp)
+	clone := *resp
+	clone.Header = resp.Header.Clone()
+	clone.Body = &bufferedBody{Reader: bytes.NewReader(data), data: data}
+	return &clone
+}
+
+// headerOverrideOf describes the per-call header override of ctx, so
+// that calls presenting different identities are not merged.
+func headerOverrideOf(ctx context.Context) string {
+	o, ok := ctx.Value(headerOverrideKey{}).(headerOverride)
+	if !ok {
+		return ""
+	}
+	key := "#" + o.userAgent
+	if o.profile != nil {
+		key += "#" + o.profile.Name + "#" + o.profile.UserAgent
+	}
+	return key
+}
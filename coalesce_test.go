@@ -0,0 +1,103 @@
+package yahoofinanceapi_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	yahoofinanceapi "github.com/oscarli916/yahoo-finance-api"
+	"github.com/oscarli916/yahoo-finance-api/yahoofinancetest"
+)
+
+// slowTransport delays every request so that concurrent calls overlap.
+type slowTransport struct {
+	delay time.Duration
+}
+
+func (t slowTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	time.Sleep(t.delay)
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func quoteRequests(server *yahoofinancetest.Server) int {
+	var n int
+	for _, r := range server.Requests() {
+		if r.Path == "/v7/finance/quote" {
+			n++
+		}
+	}
+	return n
+}
+
+func TestCoalescing(t *testing.T) {
+	server := yahoofinancetest.NewServer()
+	defer server.Close()
+	server.SetQuote(yahoofinanceapi.StockQuote{Symbol: "AAPL", RegularMarketPrice: 190})
+	client := server.NewClient(yahoofinanceapi.WithTransport(slowTransport{delay: 50 * time.Millisecond}))
+	quote := yahoofinanceapi.NewQuoteWithClient(client)
+	if _, err := quote.GetQuote("AAPL"); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if q, err := quote.GetQuote("AAPL"); err != nil || q.RegularMarketPrice != 190 {
+				t.Errorf("GetQuote = %v, %v", q.RegularMarketPrice, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := quoteRequests(server); n != 2 {
+		t.Errorf("server got %d quote requests, want 2", n)
+	}
+	if n := client.Coalesced(); n != 9 {
+		t.Errorf("Coalesced() = %d, want 9", n)
+	}
+}
+
+func TestCoalescingKeepsPrioritiesApart(t *testing.T) {
+	server := yahoofinancetest.NewServer()
+	defer server.Close()
+	server.SetQuote(yahoofinanceapi.StockQuote{Symbol: "AAPL", RegularMarketPrice: 190})
+	client := server.NewClient(
+		yahoofinanceapi.WithTransport(slowTransport{delay: 50 * time.Millisecond}),
+		yahoofinanceapi.WithEndpointBudget(yahoofinanceapi.EndpointQuote, yahoofinanceapi.Budget{Hourly: 1}),
+	)
+	quote := yahoofinanceapi.NewQuoteWithClient(client)
+	if _, err := quote.GetQuote("AAPL"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The interactive call goes over the budget, which only interactive
+	// calls may do. The batch call must not ride along with it.
+	var wg sync.WaitGroup
+	var interactiveErr, batchErr error
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		_, interactiveErr = quote.GetQuote("AAPL")
+	}()
+	go func() {
+		defer wg.Done()
+		time.Sleep(10 * time.Millisecond)
+		ctx := yahoofinanceapi.ContextWithPriority(context.Background(), yahoofinanceapi.PriorityBatch)
+		_, batchErr = quote.GetQuoteContext(ctx, "AAPL")
+	}()
+	wg.Wait()
+
+	if interactiveErr != nil {
+		t.Errorf("interactive call failed: %v", interactiveErr)
+	}
+	if !errors.Is(batchErr, yahoofinanceapi.ErrBudgetExhausted) {
+		t.Errorf("batch call error = %v, want ErrBudgetExhausted", batchErr)
+	}
+	if n := client.Coalesced(); n != 0 {
+		t.Errorf("Coalesced() = %d, want 0", n)
+	}
+}
@@ -0,0 +1,162 @@
+package yahoofinanceapi
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*
+ * EU consent (GUCE) flow
+ *
+ * From EU egress IPs fc.yahoo.com redirects to guce.yahoo.com and then to
+ * consent.yahoo.com, which serves a form instead of issuing the A1/A3
+ * cookies. Accepting it looks like
+ *
+ *	<form method="post" action="">
+ *	  <input type="hidden" name="csrfToken" value="...">
+ *	  <input type="hidden" name="sessionId" value="...">
+ *	  <button type="submit" name="agree" value="agree">
+ *
+ * and answers with redirects that set the cookies on .yahoo.com.
+ */
+
+var (
+	formPattern  = regexp.MustCompile(`(?is)<form\b([^>]*)>(.*?)</form>`)
+	inputPattern = regexp.MustCompile(`(?is)<input\b[^>]*>`)
+	attrPattern  = regexp.MustCompile(`(?is)\b([a-z-]+)\s*=\s*(?:"([^"]*)"|'([^']*)')`)
+)
+
+type consentForm struct {
+	action *url.URL
+	values url.Values
+}
+
+// findConsentForm returns the consent form of resp, or nil if resp is
+// not a consent page.
+func findConsentForm(resp *http.Response) *consentForm {
+	body := bodyBytes(resp)
+	for _, m := range formPattern.FindAllSubmatch(body, -1) {
+		formAttrs := parseAttrs(string(m[1]))
+		inner := string(m[2])
+		if !strings.Contains(inner, "csrfToken") && !strings.Contains(inner, `"agree"`) {
+			continue
+		}
+
+		values := url.Values{}
+		for _, input := range inputPattern.FindAllString(inner, -1) {
+			attrs := parseAttrs(input)
+			if strings.EqualFold(attrs["type"], "hidden") && attrs["name"] != "" {
+				values.Add(attrs["name"], attrs["value"])
+			}
+		}
+		values.Set("agree", "agree")
+
+		base := &url.URL{}
+		if resp.Request != nil {
+			base = resp.Request.URL
+		}
+		action, err := base.Parse(formAttrs["action"])
+		if err != nil {
+			return nil
+		}
+		return &consentForm{action: action, values: values}
+	}
+	return nil
+}
+
+func parseAttrs(tag string) map[string]string {
+	attrs := make(map[string]string)
+	for _, m := range attrPattern.FindAllStringSubmatch(tag, -1) {
+		v := m[2]
+		if v == "" {
+			v = m[3]
+		}
+		attrs[strings.ToLower(m[1])] = html.UnescapeString(v)
+	}
+	return attrs
+}
+
+// submitConsent posts the consent form with hc, whose cookie jar collects
+// the cookies set along the redirect chain.
+func (c *Client) submitConsent(ctx context.Context, r *route, hc *http.Client, form *consentForm, s *session) error {
+	req, err := c.newRequest(ctx, http.MethodPost, form.action.String(), nil, strings.NewReader(form.values.Encode()), s)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,*/*;q=0.8")
+	resp, err := c.send(ctx, r, s, hc, EndpointCookie, req)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrConsentRequired, err)
+	}
+	if resp.StatusCode >= 400 || findConsentForm(resp) != nil {
+		return fmt.Errorf("%w: consent form was not accepted (status %d)", ErrConsentRequired, resp.StatusCode)
+	}
+	return nil
+}
+
+// sessionJar is the cookie jar of a session bootstrap. It remembers when
+// each cookie expires, which cookiejar.Jar does not report, so that a
+// stored session can be dropped once its cookies run out.
+type sessionJar struct {
+	*cookiejar.Jar
+
+	mu      sync.Mutex
+	expires map[string]time.Time
+}
+
+func newSessionJar() (*sessionJar, error) {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, err
+	}
+	return &sessionJar{Jar: jar, expires: make(map[string]time.Time)}, nil
+}
+
+func (j *sessionJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	now := time.Now()
+	j.mu.Lock()
+	for _, cookie := range cookies {
+		switch {
+		case cookie.MaxAge > 0:
+			j.expires[cookie.Name] = now.Add(time.Duration(cookie.MaxAge) * time.Second)
+		case cookie.MaxAge == 0 && !cookie.Expires.IsZero():
+			j.expires[cookie.Name] = cookie.Expires
+		default:
+			delete(j.expires, cookie.Name)
+		}
+	}
+	j.mu.Unlock()
+	j.Jar.SetCookies(u, cookies)
+}
+
+// collectCookies returns the cookies jar holds for any of urls, without
+// duplicates and with the expiry they were set with.
+func collectCookies(jar *sessionJar, urls ...string) []*http.Cookie {
+	seen := make(map[string]bool)
+	var cookies []*http.Cookie
+	for _, raw := range urls {
+		u, err := url.Parse(raw)
+		if err != nil {
+			continue
+		}
+		for _, cookie := range jar.Cookies(u) {
+			if !seen[cookie.Name] {
+				seen[cookie.Name] = true
+				jar.mu.Lock()
+				cookie.Expires = jar.expires[cookie.Name]
+				jar.mu.Unlock()
+				cookies = append(cookies, cookie)
+			}
+		}
+	}
+	return cookies
+}
@@ -1,6 +1,7 @@
 package yahoofinanceapi
 
 var BASE_URL = "https://query2.finance.yahoo.com"
+var COOKIE_URL = "https://fc.yahoo.com"
 var USER_AGENTS = []string{
 	// Chrome (Windows, Mac, Linux)
 	"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.6367.91 Safari/537.36",
@@ -30,3 +31,40 @@ var USER_AGENTS = []string{
 	// Samsung Internet (Android)
 	"Mozilla/5.0 (Linux; Android 14; SM-G991N) AppleWebKit/537.36 (KHTML, like Gecko) SamsungBrowser/25.0 Chrome/124.0.6367.91 Mobile Safari/537.36",
 }
+
+const defaultAcceptLanguage = "en-US,en;q=0.9,ko;q=0.8"
+
+// DefaultHeaderProfiles pairs every entry of USER_AGENTS with the client
+// hints that browser sends.
+var DefaultHeaderProfiles = []HeaderProfile{
+	// Chrome (Windows, Mac, Linux)
+	{Name: "chrome-windows", UserAgent: USER_AGENTS[0], AcceptLanguage: defaultAcceptLanguage,
+		SecCHUA: `"Chromium";v="124", "Google Chrome";v="124", "Not-A.Brand";v="99"`, SecCHUAMobile: "?0", SecCHUAPlatform: `"Windows"`},
+	{Name: "chrome-mac", UserAgent: USER_AGENTS[1], AcceptLanguage: defaultAcceptLanguage,
+		SecCHUA: `"Chromium";v="124", "Google Chrome";v="124", "Not-A.Brand";v="99"`, SecCHUAMobile: "?0", SecCHUAPlatform: `"macOS"`},
+	{Name: "chrome-linux", UserAgent: USER_AGENTS[2], AcceptLanguage: defaultAcceptLanguage,
+		SecCHUA: `"Chromium";v="124", "Google Chrome";v="124", "Not-A.Brand";v="99"`, SecCHUAMobile: "?0", SecCHUAPlatform: `"Linux"`},
+
+	// Chrome (Android)
+	{Name: "chrome-android", UserAgent: USER_AGENTS[3], AcceptLanguage: defaultAcceptLanguage,
+		SecCHUA: `"Chromium";v="124", "Google Chrome";v="124", "Not-A.Brand";v="99"`, SecCHUAMobile: "?1", SecCHUAPlatform: `"Android"`},
+
+	// Firefox (Windows, Mac, Linux)
+	{Name: "firefox-windows", UserAgent: USER_AGENTS[4], AcceptLanguage: defaultAcceptLanguage},
+	{Name: "firefox-mac", UserAgent: USER_AGENTS[5], AcceptLanguage: defaultAcceptLanguage},
+	{Name: "firefox-linux", UserAgent: USER_AGENTS[6], AcceptLanguage: defaultAcceptLanguage},
+
+	// Safari (Mac, iPhone)
+	{Name: "safari-mac", UserAgent: USER_AGENTS[7], AcceptLanguage: defaultAcceptLanguage},
+	{Name: "safari-iphone", UserAgent: USER_AGENTS[8], AcceptLanguage: defaultAcceptLanguage},
+
+	// Edge (Windows, Mac)
+	{Name: "edge-windows", UserAgent: USER_AGENTS[9], AcceptLanguage: defaultAcceptLanguage,
+		SecCHUA: `"Chromium";v="124", "Microsoft Edge";v="124", "Not-A.Brand";v="99"`, SecCHUAMobile: "?0", SecCHUAPlatform: `"Windows"`},
+	{Name: "edge-mac", UserAgent: USER_AGENTS[10], AcceptLanguage: defaultAcceptLanguage,
+		SecCHUA: `"Chromium";v="124", "Microsoft Edge";v="124", "Not-A.Brand";v="99"`, SecCHUAMobile: "?0", SecCHUAPlatform: `"macOS"`},
+
+	// Samsung Internet (Android)
+	{Name: "samsung-android", UserAgent: USER_AGENTS[11], AcceptLanguage: defaultAcceptLanguage,
+		SecCHUA: `"Chromium";v="124", "Samsung Internet";v="25", "Not-A.Brand";v="99"`, SecCHUAMobile: "?1", SecCHUAPlatform: `"Android"`},
+}
@@ -0,0 +1,144 @@
+package yahoofinanceapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Sentinel errors for the failure classes callers usually branch on.
+// Use errors.Is; an *APIError matches the sentinel that fits its status.
+var (
+	ErrSymbolNotFound = errors.New("yahoo finance: symbol not found")
+	ErrRateLimited    = errors.New("yahoo finance: rate limited")
+	ErrUnauthorized   = errors.New("yahoo finance: unauthorized")
+	ErrInvalidQuery   = errors.New("yahoo finance: invalid query")
+
+	// ErrNoOptions means the symbol exists but has no listed options.
+	ErrNoOptions = errors.New("yahoo finance: no options listed")
+	// ErrExpirationNotAvailable means the requested expiration date is
+	// not one of the symbol's listed expirations.
+	ErrExpirationNotAvailable = errors.New("yahoo finance: expiration not available")
+
+	// ErrResponseTooLarge means a body exceeded the client's size limit.
+	ErrResponseTooLarge = errors.New("yahoo finance: response too large")
+	// ErrUnexpectedContent means a 2xx response did not carry the
+	// expected content, e.g. an HTML interstitial instead of JSON.
+	ErrUnexpectedContent = errors.New("yahoo finance: unexpected response content")
+	// ErrConsentRequired means Yahoo answered with its consent page.
+	ErrConsentRequired = errors.New("yahoo finance: consent required")
+	// ErrInvalidCrumb means the crumb endpoint returned something that is
+	// not a crumb; it is never cached.
+	ErrInvalidCrumb = errors.New("yahoo finance: invalid crumb")
+	// ErrCircuitOpen means the call was refused without reaching Yahoo
+	// because the circuit breaker of its endpoint family is open.
+	ErrCircuitOpen = errors.New("yahoo finance: circuit breaker open")
+	// ErrBudgetExhausted means a batch call was refused because it would
+	// exceed the client's request budget.
+	ErrBudgetExhausted = errors.New("yahoo finance: request budget exhausted")
+)
+
+// APIError is returned when Yahoo answers with a non-2xx status.
+type APIError struct {
+	Endpoint    Endpoint
+	StatusCode  int
+	Code        string
+	Description string
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("yahoo finance: %s returned %d", e.Endpoint, e.StatusCode)
+	if e.Code != "" {
+		msg += " " + e.Code
+	}
+	if e.Description != "" {
+		msg += ": " + e.Description
+	}
+	return msg
+}
+
+// Is maps the error onto ErrRateLimited, ErrUnauthorized,
+// ErrSymbolNotFound or ErrInvalidQuery.
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+	case ErrSymbolNotFound:
+		return e.StatusCode == http.StatusNotFound || e.Code == "Not Found"
+	case ErrInvalidQuery:
+		return e.StatusCode == http.StatusBadRequest || e.Code == "Bad Request"
+	}
+	return false
+}
+
+// YahooError is the error object Yahoo embeds in every response
+// envelope, e.g.
+//
+//	{"chart":{"result":null,"error":{"code":"Not Found","description":"No data found, symbol may be delisted"}}}
+type YahooError struct {
+	Code        string `json:"code"`
+	Description string `json:"description"`
+}
+
+// UnmarshalJSON also accepts a bare string, which some endpoints use
+// instead of an object.
+func (e *YahooError) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		e.Description = s
+		return nil
+	}
+	type plain YahooError
+	return json.Unmarshal(data, (*plain)(e))
+}
+
+func (e *YahooError) Error() string {
+	if e.Description == "" {
+		return e.Code
+	}
+	return e.Code + ": " + e.Description
+}
+
+// apiError wraps e into an *APIError for the given endpoint and status.
+func (e *YahooError) apiError(endpoint Endpoint, status int) *APIError {
+	return &APIError{
+		Endpoint:    endpoint,
+		StatusCode:  status,
+		Code:        e.Code,
+		Description: e.Description,
+	}
+}
+
+// yahooEnvelope matches any Yahoo response, whatever the name of its
+// top-level key ("chart", "quoteResponse", "optionChain", "finance", ...).
+type yahooEnvelope map[string]struct {
+	Error *YahooError `json:"error"`
+}
+
+// newAPIError builds the error for a non-2xx response and closes its body.
+// Code and Description are taken from the error envelope when the body
+// has one.
+func (c *Client) newAPIError(rawURL string, resp *http.Response) *APIError {
+	defer resp.Body.Close()
+	apiErr := &APIError{
+		Endpoint:   c.endpointOf(rawURL),
+		StatusCode: resp.StatusCode,
+	}
+
+	var envelope yahooEnvelope
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if json.Unmarshal(body, &envelope) == nil {
+		for _, v := range envelope {
+			if v.Error != nil {
+				apiErr.Code = v.Error.Code
+				apiErr.Description = v.Error.Description
+				break
+			}
+		}
+	}
+	return apiErr
+}
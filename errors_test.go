@@ -0,0 +1,80 @@
+package yahoofinanceapi_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	yahoofinanceapi "github.com/oscarli916/yahoo-finance-api"
+	"github.com/oscarli916/yahoo-finance-api/yahoofinancetest"
+)
+
+func TestUnknownSymbol(t *testing.T) {
+	server := yahoofinancetest.NewServer()
+	defer server.Close()
+	client := server.NewClient(yahoofinanceapi.WithLogger(nil))
+
+	_, err := yahoofinanceapi.NewHistoryWithClient(client).GetHistory("NOPE")
+	var apiErr *yahoofinanceapi.APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("GetHistory error = %v, want an *APIError", err)
+	}
+	if apiErr.StatusCode != http.StatusNotFound || apiErr.Code != "Not Found" || apiErr.Endpoint != yahoofinanceapi.EndpointChart {
+		t.Errorf("APIError = %+v, want the chart's 404 Not Found envelope", apiErr)
+	}
+	if !errors.Is(err, yahoofinanceapi.ErrSymbolNotFound) {
+		t.Errorf("%v does not match ErrSymbolNotFound", err)
+	}
+
+	if _, err := yahoofinanceapi.NewQuoteWithClient(client).GetQuote("NOPE"); !errors.Is(err, yahoofinanceapi.ErrSymbolNotFound) {
+		t.Errorf("GetQuote error = %v, want ErrSymbolNotFound", err)
+	}
+	if _, err := yahoofinanceapi.NewTickerWithClient("NOPE", client).OptionChain(); !errors.Is(err, yahoofinanceapi.ErrSymbolNotFound) {
+		t.Errorf("OptionChain error = %v, want ErrSymbolNotFound", err)
+	}
+}
+
+func TestInvalidQuery(t *testing.T) {
+	server := yahoofinancetest.NewServer()
+	defer server.Close()
+	client := server.NewClient(yahoofinanceapi.WithLogger(nil))
+
+	history := yahoofinanceapi.NewHistoryWithClient(client)
+	history.SetQuery(yahoofinanceapi.HistoryQuery{Start: "01/02/2024"})
+	if _, err := history.GetHistory("AAPL"); !errors.Is(err, yahoofinanceapi.ErrInvalidQuery) {
+		t.Errorf("GetHistory error = %v, want ErrInvalidQuery", err)
+	}
+	if _, err := yahoofinanceapi.NewQuoteWithClient(client).GetMultipleQuotes(nil); !errors.Is(err, yahoofinanceapi.ErrInvalidQuery) {
+		t.Errorf("GetMultipleQuotes error = %v, want ErrInvalidQuery", err)
+	}
+	if _, err := yahoofinanceapi.NewOptionWithClient(client).GetOptionChainByExpiration("AAPL", "next friday"); !errors.Is(err, yahoofinanceapi.ErrInvalidQuery) {
+		t.Errorf("GetOptionChainByExpiration error = %v, want ErrInvalidQuery", err)
+	}
+	if n := len(server.Requests()); n != 0 {
+		t.Errorf("server got %d requests for invalid queries, want 0", n)
+	}
+}
+
+func TestUnexpectedContent(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/test/getcrumb":
+			fmt.Fprint(w, "abcDEF123xy")
+		case "/v7/finance/quote":
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprint(w, "<html><body>Will be right back...</body></html>")
+		}
+	}))
+	defer upstream.Close()
+	client := yahoofinanceapi.NewClient(
+		yahoofinanceapi.WithBaseURL(upstream.URL),
+		yahoofinanceapi.WithCookieURL(upstream.URL+"/cookie"),
+		yahoofinanceapi.WithLogger(nil),
+	)
+
+	if _, err := yahoofinanceapi.NewQuoteWithClient(client).GetQuote("AAPL"); !errors.Is(err, yahoofinanceapi.ErrUnexpectedContent) {
+		t.Errorf("GetQuote error = %v, want ErrUnexpectedContent", err)
+	}
+}
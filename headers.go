@@ -0,0 +1,100 @@
+package yahoofinanceapi
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+)
+
+// HeaderProfile is one consistent browser identity. A profile is picked
+// when a session is created and used for every request of that session,
+// so Yahoo never sees one cookie/crumb pair from several browsers.
+type HeaderProfile struct {
+	Name            string `json:"name"`
+	UserAgent       string `json:"userAgent"`
+	AcceptLanguage  string `json:"acceptLanguage,omitempty"`
+	SecCHUA         string `json:"secChUa,omitempty"`
+	SecCHUAMobile   string `json:"secChUaMobile,omitempty"`
+	SecCHUAPlatform string `json:"secChUaPlatform,omitempty"`
+}
+
+// apply sets the headers of p on h. Client hints are only sent by
+// Chromium based browsers, so empty fields are left out.
+func (p HeaderProfile) apply(h http.Header) {
+	h.Set("User-Agent", p.UserAgent)
+	if p.AcceptLanguage != "" {
+		h.Set("Accept-Language", p.AcceptLanguage)
+	}
+	if p.SecCHUA != "" {
+		h.Set("Sec-CH-UA", p.SecCHUA)
+		h.Set("Sec-CH-UA-Mobile", p.SecCHUAMobile)
+		h.Set("Sec-CH-UA-Platform", p.SecCHUAPlatform)
+	}
+}
+
+// WithHeaderProfiles replaces DefaultHeaderProfiles as the set of
+// identities sessions are given.
+func WithHeaderProfiles(profiles ...HeaderProfile) ClientOption {
+	return func(c *Client) {
+		if len(profiles) > 0 {
+			c.headerProfiles = append([]HeaderProfile(nil), profiles...)
+		}
+	}
+}
+
+// pickHeaderProfile chooses the identity of a new session.
+func (c *Client) pickHeaderProfile() HeaderProfile {
+	return c.headerProfiles[rand.Intn(len(c.headerProfiles))]
+}
+
+type headerOverrideKey struct{}
+
+type headerOverride struct {
+	profile   *HeaderProfile
+	userAgent string
+}
+
+// ContextWithHeaderProfile makes the calls made with the returned context
+// present p instead of the identity of the current session. The session
+// bootstrap itself always keeps the session's identity.
+func ContextWithHeaderProfile(ctx context.Context, p HeaderProfile) context.Context {
+	o, _ := ctx.Value(headerOverrideKey{}).(headerOverride)
+	o.profile = &p
+	return context.WithValue(ctx, headerOverrideKey{}, o)
+}
+
+// contextWithUserAgent overrides only the User-Agent header.
+func contextWithUserAgent(ctx context.Context, ua string) context.Context {
+	o, _ := ctx.Value(headerOverrideKey{}).(headerOverride)
+	o.userAgent = ua
+	return context.WithValue(ctx, headerOverrideKey{}, o)
+}
+
+// withoutHeaderOverride drops per-call overrides, for requests that must
+// use the identity of the session.
+func withoutHeaderOverride(ctx context.Context) context.Context {
+	if ctx.Value(headerOverrideKey{}) == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, headerOverrideKey{}, headerOverride{})
+}
+
+// requestProfile returns the identity a request made with ctx on behalf
+// of s presents.
+func (c *Client) requestProfile(ctx context.Context, s *session) HeaderProfile {
+	var p HeaderProfile
+	if s != nil {
+		p = s.profile
+	} else {
+		p = c.pickHeaderProfile()
+	}
+	if o, ok := ctx.Value(headerOverrideKey{}).(headerOverride); ok {
+		if o.profile != nil {
+			p = *o.profile
+		}
+		if o.userAgent != "" {
+			p.UserAgent = o.userAgent
+		}
+	}
+	return p
+}
@@ -0,0 +1,141 @@
+package yahoofinanceapi_test
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	yahoofinanceapi "github.com/oscarli916/yahoo-finance-api"
+	"github.com/oscarli916/yahoo-finance-api/yahoofinancetest"
+)
+
+var testProfiles = []yahoofinanceapi.HeaderProfile{
+	{Name: "chrome-win", UserAgent: "Chrome/Windows", SecCHUA: `"Chromium";v="124"`, SecCHUAMobile: "?0", SecCHUAPlatform: `"Windows"`},
+	{Name: "chrome-mac", UserAgent: "Chrome/macOS", SecCHUA: `"Chromium";v="123"`, SecCHUAMobile: "?0", SecCHUAPlatform: `"macOS"`},
+	{Name: "edge-win", UserAgent: "Edge/Windows", SecCHUA: `"Microsoft Edge";v="124"`, SecCHUAMobile: "?0", SecCHUAPlatform: `"Windows"`},
+	{Name: "firefox-linux", UserAgent: "Firefox/Linux"},
+}
+
+// sentHeaders is what one request presented.
+type sentHeaders struct {
+	path, userAgent, secCHUA string
+}
+
+// headerRecorder records the identity headers of every request it passes
+// on, grouping them by session: each cookie request starts a new one.
+type headerRecorder struct {
+	mu       sync.Mutex
+	sessions [][]sentHeaders
+}
+
+func (h *headerRecorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	h.mu.Lock()
+	if req.URL.Path == "/cookie" || len(h.sessions) == 0 {
+		h.sessions = append(h.sessions, nil)
+	}
+	last := len(h.sessions) - 1
+	h.sessions[last] = append(h.sessions[last], sentHeaders{req.URL.Path, req.Header.Get("User-Agent"), req.Header.Get("Sec-CH-UA")})
+	h.mu.Unlock()
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func (h *headerRecorder) lastSession() []sentHeaders {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.sessions[len(h.sessions)-1]
+}
+
+func headerServer() *yahoofinancetest.Server {
+	server := quoteServer()
+	server.SetBars("AAPL", []yahoofinancetest.Bar{{Time: time.Date(2024, 1, 2, 14, 30, 0, 0, time.UTC), Close: 185}})
+	return server
+}
+
+func TestHeaderProfilePerSession(t *testing.T) {
+	server := headerServer()
+	defer server.Close()
+	recorder := &headerRecorder{}
+	client := server.NewClient(
+		yahoofinanceapi.WithTransport(recorder),
+		yahoofinanceapi.WithHeaderProfiles(testProfiles...),
+		yahoofinanceapi.WithLogger(nil),
+	)
+	quote := yahoofinanceapi.NewQuoteWithClient(client)
+	history := yahoofinanceapi.NewHistoryWithClient(client)
+
+	for i := 0; i < 20; i++ {
+		getQuotesThrough(t, quote, 2)
+		if _, err := history.GetHistory("AAPL"); err != nil {
+			t.Fatal(err)
+		}
+		client.RotateSession()
+	}
+
+	profiles := make(map[string]bool)
+	for i, requests := range recorder.sessions {
+		if len(requests) != 5 {
+			t.Fatalf("session %d made %d requests, want cookie, crumb, 2 quotes and a chart", i, len(requests))
+		}
+		first := requests[0]
+		for _, r := range requests[1:] {
+			if r.userAgent != first.userAgent || r.secCHUA != first.secCHUA {
+				t.Errorf("session %d: %s sent %q %q, want the %q %q of %s", i, r.path, r.userAgent, r.secCHUA, first.userAgent, first.secCHUA, first.path)
+			}
+		}
+		profiles[first.userAgent] = true
+	}
+	if len(profiles) < 2 {
+		t.Errorf("20 sessions all used %v, want rotation to pick new profiles", profiles)
+	}
+}
+
+func TestHeaderProfileOverride(t *testing.T) {
+	override := yahoofinanceapi.HeaderProfile{Name: "safari", UserAgent: "Safari/macOS"}
+	for _, tc := range []struct {
+		name          string
+		query         yahoofinanceapi.HistoryQuery
+		wantUserAgent string
+		keepSecCHUA   bool
+	}{
+		{"user agent", yahoofinanceapi.HistoryQuery{UserAgent: "custom/1.0"}, "custom/1.0", true},
+		{"profile", yahoofinanceapi.HistoryQuery{HeaderProfile: &override}, "Safari/macOS", false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			server := headerServer()
+			defer server.Close()
+			recorder := &headerRecorder{}
+			client := server.NewClient(
+				yahoofinanceapi.WithTransport(recorder),
+				yahoofinanceapi.WithHeaderProfiles(testProfiles[0]),
+				yahoofinanceapi.WithLogger(nil),
+			)
+			history := yahoofinanceapi.NewHistoryWithClient(client)
+			history.SetQuery(tc.query)
+			if _, err := history.GetHistory("AAPL"); err != nil {
+				t.Fatal(err)
+			}
+			getQuotesThrough(t, yahoofinanceapi.NewQuoteWithClient(client), 1)
+
+			session := testProfiles[0]
+			want := []sentHeaders{
+				{"/cookie", session.UserAgent, session.SecCHUA},
+				{"/v1/test/getcrumb", session.UserAgent, session.SecCHUA},
+				{"/v8/finance/chart/AAPL", tc.wantUserAgent, ""},
+				{"/v7/finance/quote", session.UserAgent, session.SecCHUA},
+			}
+			if tc.keepSecCHUA {
+				want[2].secCHUA = session.SecCHUA
+			}
+			got := recorder.lastSession()
+			if len(got) != len(want) {
+				t.Fatalf("requests = %+v, want %+v", got, want)
+			}
+			for i := range want {
+				if got[i] != want[i] {
+					t.Errorf("request %d = %+v, want %+v", i, got[i], want[i])
+				}
+			}
+		})
+	}
+}
@@ -1,13 +1,13 @@
 package yahoofinanceapi
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"log"
 	"log/slog"
-	"math/rand"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -18,6 +18,7 @@ type YahooHistoryRespose struct {
 
 type YahooChart struct {
 	Result []YahooHistoryResult `json:"result"`
+	Error  *YahooError          `json:"error"`
 }
 
 type YahooHistoryResult struct {
@@ -68,8 +69,18 @@ type YahooTradingPeriod struct {
 //
 // 반환값:
 // - [][]YahooTradingPeriod: 파싱된 거래 기간 데이터
-// - error: 파싱 중 발생한 오류
+// - error: 파싱 중 발생한 오류 (해석할 수 없는 데이터는 오류 없이 빈 배열로 반환)
 func (ym *YahooMeta) GetTradingPeriods() ([][]YahooTradingPeriod, error) {
+	periods, err := ym.parseTradingPeriods()
+	if err != nil {
+		// 둘 다 실패하면 빈 배열 반환 (GetHistory가 Client의 logger로 경고를 남깁니다)
+		return [][]YahooTradingPeriod{}, nil
+	}
+	return periods, nil
+}
+
+// parseTradingPeriods는 GetTradingPeriods와 같지만 해석할 수 없는 데이터에 대해 오류를 반환합니다.
+func (ym *YahooMeta) parseTradingPeriods() ([][]YahooTradingPeriod, error) {
 	if len(ym.TradingPeriods) == 0 {
 		return nil, nil
 	}
@@ -83,9 +94,7 @@ func (ym *YahooMeta) GetTradingPeriods() ([][]YahooTradingPeriod, error) {
 	// 실패하면 객체로 파싱 시도
 	var periodsMap map[string]interface{}
 	if err := json.Unmarshal(ym.TradingPeriods, &periodsMap); err != nil {
-		// 둘 다 실패하면 빈 배열 반환
-		log.Printf("Failed to parse tradingPeriods: %v", err)
-		return [][]YahooTradingPeriod{}, nil
+		return nil, fmt.Errorf("failed to parse tradingPeriods: %w", err)
 	}
 
 	// 객체에서 유용한 데이터를 추출하여 배열로 변환
@@ -128,15 +137,20 @@ func (ym *YahooMeta) GetTradingPeriods() ([][]YahooTradingPeriod, error) {
 // 매개변수:
 // - symbol: 디버깅할 심볼
 // - data: Yahoo 응답 데이터
+//
+// 결과는 Client의 logger에 Debug 레벨로 기록되며, Debug 레벨이 꺼져 있으면 분석을 생략합니다.
 func (h *History) DebugVolumeData(symbol string, data YahooHistoryRespose) {
+	logger := h.client.log().With("symbol", symbol)
+	if !logger.Enabled(context.Background(), slog.LevelDebug) {
+		return
+	}
 	if len(data.Chart.Result) == 0 {
-		log.Printf("No chart results for %s", symbol)
+		logger.Debug("No chart results")
 		return
 	}
 
 	result := data.Chart.Result[0]
-	log.Printf("\n=== Volume Analysis for %s (Prepost: %t) ===", symbol, h.query.Prepost)
-	log.Printf("Total data points: %d", len(result.Timestamp))
+	logger = logger.With("prepost", h.query.Prepost, "points", len(result.Timestamp))
 
 	if len(result.Indicators.Quote) > 0 {
 		quote := result.Indicators.Quote[0]
@@ -185,30 +199,27 @@ func (h *History) DebugVolumeData(symbol string, data YahooHistoryRespose) {
 			}
 		}
 
-		log.Printf("Volume Statistics:")
-		log.Printf("  Total Volume: %d", totalVolume)
-		log.Printf("  Non-zero entries: %d/%d (%.1f%%)", nonZeroVolume, len(quote.Volume),
-			float64(nonZeroVolume)/float64(len(quote.Volume))*100)
+		logger.Debug("Volume statistics",
+			"total_volume", totalVolume,
+			"non_zero", nonZeroVolume,
+			"entries", len(quote.Volume))
 
 		if h.query.Prepost {
-			log.Printf("Time-based Volume Distribution:")
-			log.Printf("  Premarket:  %d volume, %d non-zero entries", premarketVolume, premarketNonZero)
-			log.Printf("  Regular:    %d volume, %d non-zero entries", regularVolume, regularNonZero)
-			log.Printf("  Postmarket: %d volume, %d non-zero entries", postmarketVolume, postmarketNonZero)
+			logger.Debug("Time-based volume distribution",
+				slog.Group("premarket", "volume", premarketVolume, "non_zero", premarketNonZero),
+				slog.Group("regular", "volume", regularVolume, "non_zero", regularNonZero),
+				slog.Group("postmarket", "volume", postmarketVolume, "non_zero", postmarketNonZero))
 
-			// 첫 5개와 마지막 5개 volume이 0이 아닌 경우만 샘플 출력
-			log.Printf("Sample volume data (non-zero only):")
+			// 첫 5개의 volume이 0이 아닌 경우만 샘플 출력
 			sampleCount := 0
 			for i := 0; i < len(quote.Volume) && sampleCount < 5; i++ {
 				if quote.Volume[i] > 0 && i < len(result.Timestamp) {
 					timestamp := time.Unix(result.Timestamp[i], 0)
-					log.Printf("  %s: %d", timestamp.Format("2006-01-02 15:04:05"), quote.Volume[i])
+					logger.Debug("Sample volume", "time", timestamp.Format("2006-01-02 15:04:05"), "volume", quote.Volume[i])
 					sampleCount++
 				}
 			}
 		}
-
-		log.Printf("=== End Volume Analysis ===\n")
 	}
 }
 
@@ -233,12 +244,15 @@ type PriceData struct {
 }
 
 type HistoryQuery struct {
-	Range     string
-	Interval  string
-	Start     string
-	End       string
-	Prepost   bool
+	Range    string
+	Interval string
+	Start    string
+	End      string
+	Prepost  bool
+	// UserAgent가 설정되면 세션의 HeaderProfile 중 User-Agent만 이 값으로 바꿔 요청합니다.
 	UserAgent string
+	// HeaderProfile이 설정되면 이 조회 요청은 세션의 HeaderProfile 대신 이 값을 사용합니다.
+	HeaderProfile *HeaderProfile
 }
 
 func (hq *HistoryQuery) SetDefault() {
@@ -249,10 +263,11 @@ func (hq *HistoryQuery) SetDefault() {
 		hq.Interval = "1d"
 	}
 	// Prepost는 기본적으로 false, 사용자가 명시적으로 설정하지 않는 한
-	if hq.Start != "" {
+	// 이미 unix time으로 변환된 Start는 그대로 둡니다 (SetDefault 재호출 대비)
+	if hq.Start != "" && !isUnixTime(hq.Start) {
 		t, err := time.Parse("2006-01-02", hq.Start)
 		if err != nil {
-			log.Printf("Failed to parse start date: %v\n", err)
+			// GetHistory는 validate에서 먼저 ErrInvalidQuery를 반환합니다
 			hq.Start = "default"
 		} else {
 			hq.Start = fmt.Sprintf("%d", t.Unix())
@@ -261,9 +276,26 @@ func (hq *HistoryQuery) SetDefault() {
 	if hq.End == "" {
 		hq.End = fmt.Sprintf("%d", time.Now().Unix())
 	}
-	if hq.UserAgent == "" {
-		hq.UserAgent = USER_AGENTS[rand.Intn(len(USER_AGENTS))]
+}
+
+// validate는 SetDefault가 값을 변환하기 전에 사용자가 입력한 조회 조건을 검사합니다.
+//
+// 반환값:
+// - error: Start가 "2006-01-02" 형식이 아니면 ErrInvalidQuery
+func (hq *HistoryQuery) validate() error {
+	if hq.Start == "" || isUnixTime(hq.Start) {
+		return nil
 	}
+	if _, err := time.Parse("2006-01-02", hq.Start); err != nil {
+		return fmt.Errorf("%w: start date %q: %v", ErrInvalidQuery, hq.Start, err)
+	}
+	return nil
+}
+
+// isUnixTime은 s가 SetDefault가 만든 unix time 문자열인지 확인합니다.
+func isUnixTime(s string) bool {
+	_, err := strconv.ParseInt(s, 10, 64)
+	return err == nil
 }
 
 type History struct {
@@ -272,7 +304,12 @@ type History struct {
 }
 
 func NewHistory() *History {
-	return &History{query: &HistoryQuery{}, client: GetClient()}
+	return NewHistoryWithClient(GetClient())
+}
+
+// NewHistoryWithClient는 지정한 Client를 사용하는 History를 생성합니다.
+func NewHistoryWithClient(client *Client) *History {
+	return &History{query: &HistoryQuery{}, client: client}
 }
 
 func (h *History) SetQuery(query HistoryQuery) {
@@ -280,7 +317,22 @@ func (h *History) SetQuery(query HistoryQuery) {
 }
 
 func (h *History) GetHistory(symbol string) (YahooHistoryRespose, error) {
+	return h.GetHistoryContext(context.Background(), symbol)
+}
+
+// GetHistoryContext는 ctx를 사용하여 GetHistory를 수행합니다.
+// ctx가 취소되면 진행 중인 요청도 함께 취소됩니다.
+func (h *History) GetHistoryContext(ctx context.Context, symbol string) (YahooHistoryRespose, error) {
+	if err := h.query.validate(); err != nil {
+		return YahooHistoryRespose{}, err
+	}
 	h.query.SetDefault()
+	if h.query.HeaderProfile != nil {
+		ctx = ContextWithHeaderProfile(ctx, *h.query.HeaderProfile)
+	}
+	if h.query.UserAgent != "" {
+		ctx = contextWithUserAgent(ctx, h.query.UserAgent)
+	}
 
 	params := url.Values{}
 	if h.query.Range != "" {
@@ -297,10 +349,10 @@ func (h *History) GetHistory(symbol string) (YahooHistoryRespose, error) {
 		params.Add("includePrePost", "false")
 	}
 
-	endpoint := fmt.Sprintf("%s/v8/finance/chart/%s", BASE_URL, symbol)
-	resp, err := h.client.Get(endpoint, params)
+	endpoint := fmt.Sprintf("%s/v8/finance/chart/%s", h.client.baseURL, symbol)
+	resp, err := h.client.GetContext(ctx, endpoint, params)
 	if err != nil {
-		slog.Error("Failed to get history", "err", err)
+		h.client.log().ErrorContext(ctx, "Failed to get history", "err", err)
 		return YahooHistoryRespose{}, err
 	}
 	defer resp.Body.Close()
@@ -310,26 +362,33 @@ func (h *History) GetHistory(symbol string) (YahooHistoryRespose, error) {
 		// tradingPeriods 관련 오류인지 확인
 		if strings.Contains(err.Error(), "tradingPeriods") {
 			// tradingPeriods 오류는 경고로 처리하고 재시도
-			slog.Warn("TradingPeriods parsing failed, retrying with custom parsing", "symbol", symbol, "err", err)
+			h.client.log().WarnContext(ctx, "TradingPeriods parsing failed, retrying with custom parsing", "symbol", symbol, "err", err)
 
 			// 응답을 다시 읽기 위해 재요청
 			resp.Body.Close()
-			resp, err = h.client.Get(endpoint, params)
+			resp, err = h.client.GetContext(ctx, endpoint, params)
 			if err != nil {
 				return YahooHistoryRespose{}, err
 			}
 			defer resp.Body.Close()
 
 			// 커스텀 파싱으로 재시도
-			return h.parseResponseWithFallback(resp, symbol)
+			return h.parseResponseWithFallback(ctx, resp, symbol)
 		}
 
-		slog.Error("Failed to decode history data JSON response", "err", err)
+		h.client.log().ErrorContext(ctx, "Failed to decode history data JSON response", "err", err)
 		return YahooHistoryRespose{}, err
 	}
 
+	if historyResponse.Chart.Error != nil {
+		return YahooHistoryRespose{}, historyResponse.Chart.Error.apiError(EndpointChart, resp.StatusCode)
+	}
 	if len(historyResponse.Chart.Result) == 0 {
-		return YahooHistoryRespose{}, fmt.Errorf("no data found for symbol: %s", symbol)
+		return YahooHistoryRespose{}, fmt.Errorf("%w: %s", ErrSymbolNotFound, symbol)
+	}
+
+	if _, err := historyResponse.Chart.Result[0].Meta.parseTradingPeriods(); err != nil {
+		h.client.log().WarnContext(ctx, "Ignoring unparseable tradingPeriods", "symbol", symbol, "err", err)
 	}
 
 	// Premarket 요청 시 volume 데이터 디버깅
@@ -343,18 +402,19 @@ func (h *History) GetHistory(symbol string) (YahooHistoryRespose, error) {
 // parseResponseWithFallback은 tradingPeriods 파싱 오류 시 대체 파싱을 수행합니다.
 //
 // 매개변수:
+// - ctx: 로그에 함께 남길 호출의 context
 // - resp: HTTP 응답
 // - symbol: 심볼명
 //
 // 반환값:
 // - YahooHistoryRespose: 파싱된 응답 데이터
 // - error: 파싱 중 발생한 오류
-func (h *History) parseResponseWithFallback(resp *http.Response, symbol string) (YahooHistoryRespose, error) {
+func (h *History) parseResponseWithFallback(ctx context.Context, resp *http.Response, symbol string) (YahooHistoryRespose, error) {
 	// 수동으로 필요한 부분만 파싱
 	var rawData map[string]interface{}
 	decoder := json.NewDecoder(resp.Body)
 	if err := decoder.Decode(&rawData); err != nil {
-		slog.Error("Failed to decode raw JSON response", "err", err)
+		h.client.log().ErrorContext(ctx, "Failed to decode raw JSON response", "err", err)
 		return YahooHistoryRespose{}, err
 	}
 
@@ -446,7 +506,7 @@ func (h *History) parseResponseWithFallback(resp *http.Response, symbol string)
 		}
 	}
 
-	return YahooHistoryRespose{}, fmt.Errorf("failed to parse response for symbol %s even with fallback method", symbol)
+	return YahooHistoryRespose{}, fmt.Errorf("%w: failed to parse response for symbol %s even with fallback method", ErrUnexpectedContent, symbol)
 }
 
 func (h *History) transformData(data YahooHistoryRespose) map[string]PriceData {
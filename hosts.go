@@ -0,0 +1,221 @@
+package yahoofinanceapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultHosts are the hosts used, in order of preference, when the
+// client talks to the default query2 base URL.
+var DefaultHosts = []string{
+	"https://query2.finance.yahoo.com",
+	"https://query1.finance.yahoo.com",
+}
+
+// DefaultHealthCheckInterval is how long an unhealthy host is left alone
+// before it is checked again when HostFailover.HealthCheckInterval is
+// not set.
+const DefaultHealthCheckInterval = 30 * time.Second
+
+// HostFailover describes the hosts a client may send API calls to.
+// Calls stick to the host that served the last successful call and move
+// to the next healthy host once it fails.
+type HostFailover struct {
+	// Hosts are base URLs such as "https://query1.finance.yahoo.com",
+	// in order of preference. Requests built from the client's base URL
+	// are sent to one of them.
+	Hosts []string
+	// FailureThreshold is the number of consecutive connection errors or
+	// 5xx responses after which a host is marked unhealthy. Defaults to 1.
+	FailureThreshold int
+	// HealthCheckInterval is how often an unhealthy host is probed.
+	HealthCheckInterval time.Duration
+	// HealthCheckPath is requested on an unhealthy host to see whether
+	// it is back. Any response below 500 counts as healthy. Defaults to
+	// "/v1/test/getcrumb".
+	HealthCheckPath string
+}
+
+// WithHostFailover sets the hosts the client fails over between. Without
+// it, a client using the default base URL fails over between
+// DefaultHosts and any other base URL is used alone.
+func WithHostFailover(f HostFailover) ClientOption {
+	return func(c *Client) {
+		c.failover = f
+	}
+}
+
+// HostStatus describes one host of the client, as returned by Hosts.
+type HostStatus struct {
+	Host string
+	// Active is true for the host that currently receives calls.
+	Active      bool
+	Healthy     bool
+	Served      int64
+	Failures    int64
+	LastFailure time.Time
+	LastCheck   time.Time
+}
+
+// host is one API host and its health.
+type host struct {
+	url      string
+	served   atomic.Int64
+	failures atomic.Int64
+
+	// guarded by Client.hostsMu
+	healthy     bool
+	streak      int
+	checking    bool
+	nextCheck   time.Time
+	lastFailure time.Time
+	lastCheck   time.Time
+}
+
+// setupHosts fills in the failover defaults and builds the host list.
+func (c *Client) setupHosts() {
+	f := &c.failover
+	if len(f.Hosts) == 0 {
+		if c.baseURL == DefaultHosts[0] {
+			f.Hosts = DefaultHosts
+		} else {
+			f.Hosts = []string{c.baseURL}
+		}
+	}
+	if f.FailureThreshold <= 0 {
+		f.FailureThreshold = 1
+	}
+	if f.HealthCheckInterval <= 0 {
+		f.HealthCheckInterval = DefaultHealthCheckInterval
+	}
+	if f.HealthCheckPath == "" {
+		f.HealthCheckPath = "/v1/test/getcrumb"
+	}
+	for _, raw := range f.Hosts {
+		c.hosts = append(c.hosts, &host{url: strings.TrimRight(raw, "/"), healthy: true})
+	}
+}
+
+// Hosts reports the health and traffic of every host of the client.
+func (c *Client) Hosts() []HostStatus {
+	c.hostsMu.Lock()
+	defer c.hostsMu.Unlock()
+	out := make([]HostStatus, len(c.hosts))
+	for i, h := range c.hosts {
+		out[i] = HostStatus{
+			Host:        h.url,
+			Active:      i == c.activeHost,
+			Healthy:     h.healthy,
+			Served:      h.served.Load(),
+			Failures:    h.failures.Load(),
+			LastFailure: h.lastFailure,
+			LastCheck:   h.lastCheck,
+		}
+	}
+	return out
+}
+
+// pickHost returns the host for the next request. The active host is
+// kept while it is healthy; otherwise the first healthy host in order of
+// preference becomes active. Unhealthy hosts that are due for a health
+// check are probed in the background through hc.
+func (c *Client) pickHost(ctx context.Context, hc *http.Client) *host {
+	if len(c.hosts) == 1 {
+		return c.hosts[0]
+	}
+	c.hostsMu.Lock()
+	defer c.hostsMu.Unlock()
+
+	if !c.hosts[c.activeHost].healthy {
+		for i, h := range c.hosts {
+			if h.healthy {
+				c.log().WarnContext(ctx, "failing over to another Yahoo Finance host", "from", c.hosts[c.activeHost].url, "to", h.url)
+				c.activeHost = i
+				break
+			}
+		}
+	}
+	now := time.Now()
+	for _, h := range c.hosts {
+		if !h.healthy && !h.checking && !now.Before(h.nextCheck) {
+			h.checking = true
+			go c.checkHost(ctx, h, hc)
+		}
+	}
+	return c.hosts[c.activeHost]
+}
+
+// rewrite points endpoint at h when it was built from the client's base
+// URL. Other URLs are returned unchanged.
+func (c *Client) rewrite(h *host, endpoint string) string {
+	rest, ok := strings.CutPrefix(endpoint, c.baseURL)
+	if !ok || (rest != "" && rest[0] != '/' && rest[0] != '?') {
+		return endpoint
+	}
+	return h.url + rest
+}
+
+// reportHost records the outcome of a request sent to h. Connection
+// errors and 5xx responses count against the host; a cancelled ctx does
+// not.
+func (c *Client) reportHost(ctx context.Context, h *host, resp *http.Response, err error) {
+	if ctx.Err() != nil {
+		return
+	}
+	h.served.Add(1)
+	failed := (err != nil && !errors.Is(err, ErrResponseTooLarge)) || (err == nil && resp.StatusCode >= 500)
+
+	c.hostsMu.Lock()
+	defer c.hostsMu.Unlock()
+	if !failed {
+		h.streak = 0
+		return
+	}
+	h.failures.Add(1)
+	h.streak++
+	h.lastFailure = time.Now()
+	if len(c.hosts) > 1 && h.healthy && h.streak >= c.failover.FailureThreshold {
+		h.healthy = false
+		h.nextCheck = h.lastFailure.Add(c.failover.HealthCheckInterval)
+		c.log().WarnContext(ctx, "Yahoo Finance host marked unhealthy", "host", h.url, "failures", h.streak)
+	}
+}
+
+// checkHost probes an unhealthy host and marks it healthy again when it
+// answers with anything below 500. The probe keeps the values of ctx,
+// the call that triggered it, but neither its cancellation nor its
+// attempt number.
+func (c *Client) checkHost(ctx context.Context, h *host, hc *http.Client) {
+	ctx, cancel := context.WithTimeout(withAttempt(context.WithoutCancel(ctx), 0), 10*time.Second)
+	defer cancel()
+
+	healthy := false
+	req, err := c.newRequest(ctx, http.MethodGet, h.url+c.failover.HealthCheckPath, nil, nil, nil)
+	if err == nil {
+		var resp *http.Response
+		start := time.Now()
+		resp, err = hc.Do(req)
+		c.requestSent(ctx, nil, nil, EndpointOther, req, start, resp, err)
+		if err == nil {
+			resp.Body.Close()
+			healthy = resp.StatusCode < 500
+		}
+	}
+
+	c.hostsMu.Lock()
+	defer c.hostsMu.Unlock()
+	h.checking = false
+	h.lastCheck = time.Now()
+	if healthy {
+		h.healthy = true
+		h.streak = 0
+		c.log().InfoContext(ctx, "Yahoo Finance host is healthy again", "host", h.url)
+		return
+	}
+	h.nextCheck = h.lastCheck.Add(c.failover.HealthCheckInterval)
+	c.log().DebugContext(ctx, "Yahoo Finance host still unhealthy", "host", h.url, "err", err)
+}
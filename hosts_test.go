@@ -0,0 +1,122 @@
+package yahoofinanceapi_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	yahoofinanceapi "github.com/oscarli916/yahoo-finance-api"
+	"github.com/oscarli916/yahoo-finance-api/yahoofinancetest"
+)
+
+// testHost fronts the fake server like one of Yahoo's query hosts. While
+// down it answers everything with 503.
+type testHost struct {
+	*httptest.Server
+	down   atomic.Bool
+	quotes atomic.Int64
+	checks atomic.Int64
+}
+
+func newTestHost(server *yahoofinancetest.Server) *testHost {
+	target, _ := url.Parse(server.URL)
+	forward := httputil.NewSingleHostReverseProxy(target)
+	h := &testHost{}
+	h.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v7/finance/quote":
+			h.quotes.Add(1)
+		case "/health":
+			h.checks.Add(1)
+		}
+		if h.down.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		forward.ServeHTTP(w, r)
+	}))
+	return h
+}
+
+// waitHealthy polls until the i-th host of client is healthy again.
+func waitHealthy(t *testing.T, client *yahoofinanceapi.Client, i int) {
+	t.Helper()
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		if client.Hosts()[i].Healthy {
+			return
+		}
+	}
+	t.Fatalf("host %d did not recover: %+v", i, client.Hosts()[i])
+}
+
+func TestHostFailover(t *testing.T) {
+	server := yahoofinancetest.NewServer()
+	defer server.Close()
+	server.SetQuote(yahoofinanceapi.StockQuote{Symbol: "AAPL", RegularMarketPrice: 190})
+	a, b := newTestHost(server), newTestHost(server)
+	defer a.Close()
+	defer b.Close()
+	client := server.NewClient(
+		yahoofinanceapi.WithHostFailover(yahoofinanceapi.HostFailover{
+			Hosts:               []string{a.URL, b.URL},
+			HealthCheckInterval: 50 * time.Millisecond,
+			HealthCheckPath:     "/health",
+		}),
+		yahoofinanceapi.WithRetryPolicy(fastRetries),
+		yahoofinanceapi.WithLogger(nil),
+	)
+	quote := yahoofinanceapi.NewQuoteWithClient(client)
+
+	getQuotesThrough(t, quote, 2)
+	if a.quotes.Load() != 2 || b.quotes.Load() != 0 {
+		t.Fatalf("hosts served %d and %d quotes, want the first one to serve both", a.quotes.Load(), b.quotes.Load())
+	}
+
+	// The first 503 marks a unhealthy and the retry goes to b.
+	a.down.Store(true)
+	getQuotesThrough(t, quote, 3)
+	if a.quotes.Load() != 3 || b.quotes.Load() != 3 {
+		t.Errorf("hosts served %d and %d quotes, want 3 each", a.quotes.Load(), b.quotes.Load())
+	}
+	if hosts := client.Hosts(); hosts[0].Healthy || hosts[0].Failures != 1 || !hosts[1].Active {
+		t.Errorf("Hosts = %+v, want the first one unhealthy and the second one active", hosts)
+	}
+
+	// A health check of a host that is still down keeps it unhealthy.
+	time.Sleep(60 * time.Millisecond)
+	getQuotesThrough(t, quote, 1)
+	for deadline := time.Now().Add(time.Second); a.checks.Load() == 0 && time.Now().Before(deadline); {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if hosts := client.Hosts(); hosts[0].Healthy {
+		t.Errorf("Hosts = %+v, want the first one still unhealthy", hosts)
+	}
+
+	// Once it is back the next check marks it healthy, but calls stick
+	// to b while b keeps working.
+	a.down.Store(false)
+	time.Sleep(60 * time.Millisecond)
+	getQuotesThrough(t, quote, 1)
+	waitHealthy(t, client, 0)
+	getQuotesThrough(t, quote, 3)
+	if a.quotes.Load() != 3 {
+		t.Errorf("recovered host served %d quotes, want calls to stay on the active host", a.quotes.Load()-3)
+	}
+	if hosts := client.Hosts(); !hosts[1].Active || hosts[0].LastCheck.IsZero() {
+		t.Errorf("Hosts = %+v, want the second one still active", hosts)
+	}
+
+	// When b fails in turn, calls move back to a.
+	b.down.Store(true)
+	getQuotesThrough(t, quote, 2)
+	if a.quotes.Load() != 5 {
+		t.Errorf("first host served %d quotes after the second one failed, want 2", a.quotes.Load()-3)
+	}
+	if hosts := client.Hosts(); !hosts[0].Active || hosts[1].Healthy {
+		t.Errorf("Hosts = %+v, want the first one active and the second one unhealthy", hosts)
+	}
+}
@@ -0,0 +1,240 @@
+package yahoofinanceapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// JournalEntry is one line of a Journal.
+type JournalEntry struct {
+	Time     time.Time `json:"time"`
+	Endpoint Endpoint  `json:"endpoint"`
+	Method   string    `json:"method"`
+	// URL is the URL without its query string, on the host that was
+	// called.
+	URL string `json:"url"`
+	// Params are the query parameters without the crumb.
+	Params    url.Values `json:"params,omitempty"`
+	Status    int        `json:"status"`
+	Error     string     `json:"error,omitempty"`
+	LatencyMS float64    `json:"latency_ms"`
+	Bytes     int        `json:"bytes"`
+	// Attempt is the attempt of the API call the request was sent for,
+	// or 0 for session bootstrap and health check requests.
+	Attempt   int    `json:"attempt"`
+	SessionID string `json:"session_id,omitempty"`
+	Host      string `json:"host,omitempty"`
+	Route     string `json:"route,omitempty"`
+	CacheHit  bool   `json:"cache_hit"`
+	Coalesced bool   `json:"coalesced"`
+}
+
+// Journal is an Observer that appends one JSON line per HTTP request sent
+// to Yahoo to a writer or to a file rotated by size. That covers the
+// cookie, consent and crumb requests of the session bootstrap, every
+// retry and replay of an API call, and host health checks. API calls
+// answered without a request, from the response cache or by sharing an
+// identical call in flight, get a line of their own with cache_hit or
+// coalesced set.
+type Journal struct {
+	mu  sync.Mutex
+	w   io.Writer
+	err error
+
+	// set for file journals
+	file       *os.File
+	path       string
+	size       int64
+	maxBytes   int64
+	maxBackups int
+}
+
+// NewJournal returns a Journal writing to w. Writes to w are serialized.
+func NewJournal(w io.Writer) *Journal {
+	return &Journal{w: w}
+}
+
+// NewFileJournal returns a Journal appending to the file at path. Once
+// the file would grow beyond maxBytes it is renamed to path.1, older
+// files are shifted up to path.<maxBackups> and a new file is started.
+// A maxBytes of 0 disables rotation.
+func NewFileJournal(path string, maxBytes int64, maxBackups int) (*Journal, error) {
+	j := &Journal{path: path, maxBytes: maxBytes, maxBackups: maxBackups}
+	if err := j.open(); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// WithJournal records every request the client sends in j.
+func WithJournal(j *Journal) ClientOption {
+	return WithObserver(j)
+}
+
+// Err returns the first error met while writing the journal.
+func (j *Journal) Err() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.err
+}
+
+// Close closes the file of a file journal. It does nothing for a journal
+// writing to an io.Writer.
+func (j *Journal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.file == nil {
+		return nil
+	}
+	err := j.file.Close()
+	j.file, j.w = nil, nil
+	return err
+}
+
+func (j *Journal) CallStarted(ctx context.Context, call CallInfo) context.Context {
+	return ctx
+}
+
+func (j *Journal) CallFinished(ctx context.Context, call CallInfo, result CallResult) {
+	// Calls that reached Yahoo are journaled request by request.
+	if !result.CacheHit && !result.Coalesced {
+		return
+	}
+	entry := JournalEntry{
+		Time:      call.Start.UTC(),
+		Endpoint:  call.Endpoint,
+		Method:    http.MethodGet,
+		URL:       call.URL,
+		Params:    call.Params,
+		Status:    result.StatusCode,
+		LatencyMS: float64(result.Latency) / float64(time.Millisecond),
+		Bytes:     result.Bytes,
+		SessionID: result.SessionID,
+		Host:      result.Host,
+		Route:     result.Route,
+		CacheHit:  result.CacheHit,
+		Coalesced: result.Coalesced,
+	}
+	if result.Err != nil {
+		entry.Error = result.Err.Error()
+	}
+	j.append(entry)
+}
+
+func (j *Journal) RequestSent(ctx context.Context, req RequestInfo) {
+	entry := JournalEntry{
+		Time:      req.Start.UTC(),
+		Endpoint:  req.Endpoint,
+		Method:    req.Method,
+		URL:       req.URL,
+		Params:    req.Params,
+		Status:    req.StatusCode,
+		LatencyMS: float64(req.Latency) / float64(time.Millisecond),
+		Bytes:     req.Bytes,
+		Attempt:   req.Attempt,
+		SessionID: req.SessionID,
+		Route:     req.Route,
+	}
+	if u, err := url.Parse(req.URL); err == nil {
+		entry.Host = u.Host
+	}
+	if req.Err != nil {
+		entry.Error = req.Err.Error()
+	}
+	j.append(entry)
+}
+
+func (j *Journal) SessionRefreshed(ctx context.Context, event RotationEvent) {}
+
+func (j *Journal) append(entry JournalEntry) {
+	if len(entry.Params) == 0 {
+		entry.Params = nil
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		j.fail(err)
+		return
+	}
+	j.write(append(line, '\n'))
+}
+
+func (j *Journal) write(line []byte) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.file != nil && j.maxBytes > 0 && j.size > 0 && j.size+int64(len(line)) > j.maxBytes {
+		if err := j.rotate(); err != nil {
+			j.setErr(err)
+			return
+		}
+	}
+	if j.w == nil {
+		j.setErr(os.ErrClosed)
+		return
+	}
+	n, err := j.w.Write(line)
+	j.size += int64(n)
+	if err != nil {
+		j.setErr(err)
+	}
+}
+
+func (j *Journal) fail(err error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.setErr(err)
+}
+
+// setErr keeps the first error. j.mu must be held.
+func (j *Journal) setErr(err error) {
+	if j.err == nil {
+		j.err = err
+	}
+}
+
+// open opens the journal file for appending. j.mu must be held or j not
+// yet shared.
+func (j *Journal) open() error {
+	if err := os.MkdirAll(filepath.Dir(j.path), 0o700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(j.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	j.file, j.w, j.size = f, f, info.Size()
+	return nil
+}
+
+// rotate shifts the backups, moves the current file to path.1 and opens
+// a new one. j.mu must be held.
+func (j *Journal) rotate() error {
+	if err := j.file.Close(); err != nil {
+		return err
+	}
+	j.file, j.w = nil, nil
+	if j.maxBackups > 0 {
+		os.Remove(fmt.Sprintf("%s.%d", j.path, j.maxBackups))
+		for i := j.maxBackups - 1; i >= 1; i-- {
+			os.Rename(fmt.Sprintf("%s.%d", j.path, i), fmt.Sprintf("%s.%d", j.path, i+1))
+		}
+		if err := os.Rename(j.path, j.path+".1"); err != nil {
+			return err
+		}
+	} else if err := os.Remove(j.path); err != nil {
+		return err
+	}
+	return j.open()
+}
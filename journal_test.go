@@ -0,0 +1,173 @@
+package yahoofinanceapi_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	yahoofinanceapi "github.com/oscarli916/yahoo-finance-api"
+	"github.com/oscarli916/yahoo-finance-api/yahoofinancetest"
+)
+
+func readJournal(t *testing.T, data []byte) []yahoofinanceapi.JournalEntry {
+	t.Helper()
+	var entries []yahoofinanceapi.JournalEntry
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		var entry yahoofinanceapi.JournalEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("journal line %q: %v", scanner.Text(), err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// summary lists entries as "METHOD path attempt status".
+func summary(entries []yahoofinanceapi.JournalEntry) []string {
+	var lines []string
+	for _, e := range entries {
+		u, _ := url.Parse(e.URL)
+		lines = append(lines, strings.Join([]string{e.Method, u.Path, strconv.Itoa(e.Attempt), strconv.Itoa(e.Status)}, " "))
+	}
+	return lines
+}
+
+func TestJournalRecordsEveryRequest(t *testing.T) {
+	server := yahoofinancetest.NewServer()
+	defer server.Close()
+	server.RequireConsent()
+	server.SetQuote(yahoofinanceapi.StockQuote{Symbol: "AAPL", RegularMarketPrice: 190})
+
+	var buf bytes.Buffer
+	journal := yahoofinanceapi.NewJournal(&buf)
+	client := server.NewClient(
+		yahoofinanceapi.WithJournal(journal),
+		yahoofinanceapi.WithRetryPolicy(fastRetries),
+		yahoofinanceapi.WithLogger(nil),
+	)
+	quote := yahoofinanceapi.NewQuoteWithClient(client)
+
+	server.Fail(yahoofinancetest.FailServerError, 1)
+	if _, err := quote.GetQuote("AAPL"); err != nil {
+		t.Fatal(err)
+	}
+	server.RevokeSession()
+	if _, err := quote.GetQuote("AAPL"); err != nil {
+		t.Fatal(err)
+	}
+	if err := journal.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	entries := readJournal(t, buf.Bytes())
+	want := []string{
+		"GET /cookie 0 200",
+		"POST /consent 0 200",
+		"GET /v1/test/getcrumb 0 200",
+		"GET /v7/finance/quote 1 503",
+		"GET /v7/finance/quote 2 200",
+		"GET /v7/finance/quote 1 401",
+		"GET /cookie 0 200",
+		"POST /consent 0 200",
+		"GET /v1/test/getcrumb 0 200",
+		"GET /v7/finance/quote 1 200",
+	}
+	if got := summary(entries); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("journal:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	for _, e := range entries {
+		if e.Host != host || !strings.HasPrefix(e.URL, server.URL+"/") {
+			t.Errorf("entry for %s has host %q, want %q", e.URL, e.Host, host)
+		}
+		if e.Params.Has("crumb") {
+			t.Errorf("entry for %s carries the crumb", e.URL)
+		}
+		if e.SessionID == "" || e.Route != "direct" {
+			t.Errorf("entry for %s has session %q and route %q", e.URL, e.SessionID, e.Route)
+		}
+	}
+	if entries[5].SessionID == entries[9].SessionID {
+		t.Error("replay was journaled with the rejected session")
+	}
+	if got := entries[4].Params.Get("symbols"); got != "AAPL" {
+		t.Errorf("quote params = %v, want symbols=AAPL", entries[4].Params)
+	}
+}
+
+func TestJournalRecordsCacheHits(t *testing.T) {
+	server := yahoofinancetest.NewServer()
+	defer server.Close()
+	server.SetQuote(yahoofinanceapi.StockQuote{Symbol: "AAPL", RegularMarketPrice: 190})
+
+	var buf bytes.Buffer
+	client := server.NewClient(
+		yahoofinanceapi.WithJournal(yahoofinanceapi.NewJournal(&buf)),
+		yahoofinanceapi.WithResponseCache(yahoofinanceapi.NewMemoryCache(10), yahoofinanceapi.DefaultCacheTTL),
+	)
+	quote := yahoofinanceapi.NewQuoteWithClient(client)
+	for i := 0; i < 2; i++ {
+		if _, err := quote.GetQuote("AAPL"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entries := readJournal(t, buf.Bytes())
+	if len(entries) != 4 {
+		t.Fatalf("journal has %d lines, want 4:\n%s", len(entries), buf.String())
+	}
+	last := entries[3]
+	if !last.CacheHit || last.Endpoint != yahoofinanceapi.EndpointQuote || last.Status != 200 {
+		t.Errorf("last entry = %+v, want a quote cache hit", last)
+	}
+	for _, e := range entries[:3] {
+		if e.CacheHit {
+			t.Errorf("request to %s marked as cache hit", e.URL)
+		}
+	}
+}
+
+func TestFileJournalRotates(t *testing.T) {
+	server := yahoofinancetest.NewServer()
+	defer server.Close()
+	server.SetQuote(yahoofinanceapi.StockQuote{Symbol: "AAPL", RegularMarketPrice: 190})
+
+	path := filepath.Join(t.TempDir(), "journal", "yahoo.jsonl")
+	journal, err := yahoofinanceapi.NewFileJournal(path, 1000, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	quote := yahoofinanceapi.NewQuoteWithClient(server.NewClient(yahoofinanceapi.WithJournal(journal)))
+	for i := 0; i < 10; i++ {
+		if _, err := quote.GetQuote("AAPL"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := journal.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := journal.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{path, path + ".1", path + ".2"} {
+		info, err := os.Stat(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Size() > 1000 {
+			t.Errorf("%s is %d bytes, want at most 1000", name, info.Size())
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("%s.3 exists, want at most 2 backups", path)
+	}
+}
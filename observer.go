@@ -0,0 +1,211 @@
+package yahoofinanceapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// CallInfo describes an API call made through Client.GetContext.
+type CallInfo struct {
+	Endpoint Endpoint
+	// URL is the endpoint URL without its query string.
+	URL string
+	// Params are the query parameters; the crumb is never included.
+	Params url.Values
+	Start  time.Time
+}
+
+// CallResult describes how an API call ended.
+type CallResult struct {
+	// StatusCode is the status of the last response, or 0 when no
+	// response was received.
+	StatusCode int
+	Err        error
+	Latency    time.Duration
+	// Bytes is the size of the returned body.
+	Bytes int
+	// Retries is the number of attempts made after the first one.
+	Retries int
+	// CacheHit is true when the call was answered from the response cache.
+	CacheHit bool
+	// Coalesced is true when the call shared the request of an identical
+	// call that was already in flight.
+	Coalesced bool
+	// SessionRefreshed is true when the session was dropped during the
+	// call, by rotation or because Yahoo rejected it.
+	SessionRefreshed bool
+	SessionID        string
+	// Host is the API host that served the last attempt.
+	Host string
+	// Route is the proxy of the last attempt, or "direct".
+	Route string
+}
+
+// Observer is notified about every API call and session refresh of a
+// Client. CallStarted may return a derived context, for example one
+// carrying a trace span; it is used for the call and passed to
+// CallFinished. Methods are called concurrently and must not block.
+type Observer interface {
+	CallStarted(ctx context.Context, call CallInfo) context.Context
+	CallFinished(ctx context.Context, call CallInfo, result CallResult)
+	SessionRefreshed(ctx context.Context, event RotationEvent)
+}
+
+// RequestInfo describes one HTTP request sent upstream: an attempt or
+// replay of an API call, a step of the session bootstrap (cookie, consent
+// form, crumb) or a host health check.
+type RequestInfo struct {
+	Endpoint Endpoint
+	Method   string
+	// URL is the request URL without its query string, on the host the
+	// request was sent to.
+	URL string
+	// Params are the query parameters; the crumb is never included.
+	Params url.Values
+	// Attempt is the attempt of the API call the request was sent for,
+	// starting at 1, or 0 for session bootstrap and health check requests.
+	Attempt    int
+	Start      time.Time
+	StatusCode int
+	Err        error
+	Latency    time.Duration
+	// Bytes is the size of the response body.
+	Bytes     int
+	SessionID string
+	// Route is the proxy the request went through, or "direct".
+	Route string
+}
+
+// RequestObserver is implemented by observers that also want to be told
+// about every HTTP request sent upstream. The client checks each of its
+// observers for it. RequestSent is called concurrently and must not
+// block.
+type RequestObserver interface {
+	RequestSent(ctx context.Context, req RequestInfo)
+}
+
+// WithObserver adds o to the observers of the client. It may be given
+// several times.
+func WithObserver(o Observer) ClientOption {
+	return func(c *Client) {
+		if o != nil {
+			c.observers = append(c.observers, o)
+		}
+	}
+}
+
+// RotationRejected is the reason reported to observers when a session is
+// dropped because Yahoo rejected its crumb or cookie.
+const RotationRejected RotationReason = "rejected"
+
+// observe runs call between the CallStarted and CallFinished
+// notifications of every observer.
+func (c *Client) observe(ctx context.Context, rawURL string, params url.Values, call func(context.Context, *CallResult) (*http.Response, error)) (*http.Response, error) {
+	if len(c.observers) == 0 {
+		var result CallResult
+		return call(ctx, &result)
+	}
+
+	info := CallInfo{
+		Endpoint: c.endpointOf(rawURL),
+		URL:      rawURL,
+		Params:   make(url.Values, len(params)),
+		Start:    time.Now(),
+	}
+	if i := strings.IndexByte(rawURL, '?'); i >= 0 {
+		info.URL = rawURL[:i]
+	}
+	for k, v := range params {
+		if k != "crumb" {
+			info.Params[k] = append([]string(nil), v...)
+		}
+	}
+	for _, o := range c.observers {
+		ctx = o.CallStarted(ctx, info)
+	}
+
+	var result CallResult
+	resp, err := call(ctx, &result)
+	result.Err = err
+	result.Latency = time.Since(info.Start)
+	if resp != nil {
+		result.StatusCode = resp.StatusCode
+		result.Bytes = len(bodyBytes(resp))
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		result.StatusCode = apiErr.StatusCode
+	}
+	for _, o := range c.observers {
+		o.CallFinished(ctx, info, result)
+	}
+	return resp, err
+}
+
+type attemptKey struct{}
+
+// withAttempt records in ctx which attempt of an API call a request
+// belongs to.
+func withAttempt(ctx context.Context, attempt int) context.Context {
+	return context.WithValue(ctx, attemptKey{}, attempt)
+}
+
+// requestSent tells the request observers about req, sent through r with
+// the session s at start. r and s may be nil.
+func (c *Client) requestSent(ctx context.Context, r *route, s *session, e Endpoint, req *http.Request, start time.Time, resp *http.Response, err error) {
+	var observers []RequestObserver
+	for _, o := range c.observers {
+		if ro, ok := o.(RequestObserver); ok {
+			observers = append(observers, ro)
+		}
+	}
+	if len(observers) == 0 {
+		return
+	}
+
+	info := RequestInfo{
+		Endpoint: e,
+		Method:   req.Method,
+		URL:      (&url.URL{Scheme: req.URL.Scheme, Host: req.URL.Host, Path: req.URL.Path}).String(),
+		Params:   req.URL.Query(),
+		Start:    start,
+		Err:      err,
+		Latency:  time.Since(start),
+	}
+	info.Params.Del("crumb")
+	info.Attempt, _ = ctx.Value(attemptKey{}).(int)
+	if r != nil {
+		info.Route = r.name
+	}
+	if s != nil {
+		info.SessionID = s.id
+	}
+	if resp != nil {
+		info.StatusCode = resp.StatusCode
+		info.Bytes = len(bodyBytes(resp))
+	}
+	for _, o := range observers {
+		o.RequestSent(ctx, info)
+	}
+}
+
+// sessionRefreshed notifies the observers that a session was dropped.
+func (c *Client) sessionRefreshed(ctx context.Context, event RotationEvent) {
+	for _, o := range c.observers {
+		o.SessionRefreshed(ctx, event)
+	}
+}
+
+// refreshEvent describes the refresh of s on r.
+func refreshEvent(r *route, s *session, reason RotationReason) RotationEvent {
+	event := RotationEvent{Reason: reason, Route: r.name, Calls: r.calls.Load()}
+	if s != nil {
+		event.SessionID = s.id
+		event.Age = time.Since(s.created)
+	}
+	return event
+}
@@ -1,9 +1,9 @@
 package yahoofinanceapi
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"log/slog"
 	"net/url"
 	"time"
 )
@@ -14,7 +14,7 @@ type YahooOptionResponse struct {
 
 type YahooOptionChain struct {
 	Result []YahooOptionResult `json:"result"`
-	Error  any                 `json:"error"`
+	Error  *YahooError         `json:"error"`
 }
 
 type YahooOptionResult struct {
@@ -170,52 +170,92 @@ type Option struct {
 }
 
 func NewOption() *Option {
-	return &Option{client: GetClient()}
+	return NewOptionWithClient(GetClient())
 }
 
-func (o *Option) GetOptionChain(symbol string) YahooOptionResponse {
-	endpoint := fmt.Sprintf("%s/v7/finance/options/%s", BASE_URL, symbol)
-	resp, err := o.client.Get(endpoint, url.Values{})
+// NewOptionWithClient returns an Option that sends its requests through client.
+func NewOptionWithClient(client *Client) *Option {
+	return &Option{client: client}
+}
+
+func (o *Option) GetOptionChain(symbol string) (YahooOptionResponse, error) {
+	return o.GetOptionChainContext(context.Background(), symbol)
+}
+
+// GetOptionChainContext returns the chain of the nearest expiration.
+// It fails with ErrNoOptions when the symbol has no listed options.
+func (o *Option) GetOptionChainContext(ctx context.Context, symbol string) (YahooOptionResponse, error) {
+	optionResponse, err := o.fetchOptionChain(ctx, symbol, url.Values{})
 	if err != nil {
-		slog.Error("Failed to get option chain", "err", err)
-		return YahooOptionResponse{}
+		o.client.log().ErrorContext(ctx, "Failed to get option chain", "err", err)
+		return YahooOptionResponse{}, err
 	}
-	defer resp.Body.Close()
-
-	var optionResponse YahooOptionResponse
-	if err := json.NewDecoder(resp.Body).Decode(&optionResponse); err != nil {
-		slog.Error("Failed to decode option data JSON response", "err", err)
+	if len(optionResponse.OptionChain.Result[0].Options) == 0 {
+		return YahooOptionResponse{}, fmt.Errorf("%w: %s", ErrNoOptions, symbol)
 	}
+	return optionResponse, nil
+}
 
-	return optionResponse
+func (o *Option) GetOptionChainByExpiration(symbol string, expirationDate string) (YahooOptionResponse, error) {
+	return o.GetOptionChainByExpirationContext(context.Background(), symbol, expirationDate)
 }
 
-func (o *Option) GetOptionChainByExpiration(symbol string, expirationDate string) YahooOptionResponse {
+// GetOptionChainByExpirationContext returns the chain expiring on
+// expirationDate ("2006-01-02"). It fails with ErrExpirationNotAvailable
+// when Yahoo does not list that date for the symbol.
+func (o *Option) GetOptionChainByExpirationContext(ctx context.Context, symbol string, expirationDate string) (YahooOptionResponse, error) {
 	t, err := time.Parse("2006-01-02", expirationDate)
 	if err != nil {
-		slog.Error("Failed to parse expiration date", "err", err)
-		return YahooOptionResponse{}
+		return YahooOptionResponse{}, fmt.Errorf("%w: expiration date %q: %v", ErrInvalidQuery, expirationDate, err)
 	}
-	endpoint := fmt.Sprintf("%s/v7/finance/options/%s", BASE_URL, symbol)
 	params := url.Values{}
 	params.Add("date", fmt.Sprintf("%d", t.Unix()))
-	resp, err := o.client.Get(endpoint, params)
+	optionResponse, err := o.fetchOptionChain(ctx, symbol, params)
 	if err != nil {
-		slog.Error("Failed to get option chain by expiration", "err", err)
-		return YahooOptionResponse{}
+		o.client.log().ErrorContext(ctx, "Failed to get option chain by expiration", "err", err)
+		return YahooOptionResponse{}, err
+	}
+
+	result := optionResponse.OptionChain.Result[0]
+	if len(result.ExpirationDates) == 0 {
+		return YahooOptionResponse{}, fmt.Errorf("%w: %s", ErrNoOptions, symbol)
+	}
+	if len(result.Options) == 0 || result.Options[0].ExpirationDate != t.Unix() {
+		return YahooOptionResponse{}, fmt.Errorf("%w: %s %s", ErrExpirationNotAvailable, symbol, expirationDate)
+	}
+	return optionResponse, nil
+}
+
+// fetchOptionChain requests /v7/finance/options/{symbol} and decodes the answer.
+func (o *Option) fetchOptionChain(ctx context.Context, symbol string, params url.Values) (YahooOptionResponse, error) {
+	endpoint := fmt.Sprintf("%s/v7/finance/options/%s", o.client.baseURL, symbol)
+	resp, err := o.client.GetContext(ctx, endpoint, params)
+	if err != nil {
+		return YahooOptionResponse{}, err
 	}
 	defer resp.Body.Close()
 
 	var optionResponse YahooOptionResponse
 	if err := json.NewDecoder(resp.Body).Decode(&optionResponse); err != nil {
-		slog.Error("Failed to decode option data JSON response", "err", err)
+		return YahooOptionResponse{}, err
 	}
-
-	return optionResponse
+	if optionResponse.OptionChain.Error != nil {
+		return YahooOptionResponse{}, optionResponse.OptionChain.Error.apiError(EndpointOptions, resp.StatusCode)
+	}
+	if len(optionResponse.OptionChain.Result) == 0 {
+		return optionResponse, fmt.Errorf("%w: %s", ErrSymbolNotFound, symbol)
+	}
+	return optionResponse, nil
 }
 
-func (o *Option) transformData(data YahooOptionResponse) OptionData {
-	date := time.Unix(data.OptionChain.Result[0].Options[0].ExpirationDate, 0).Format("2006-01-02")
+func (o *Option) transformData(data YahooOptionResponse) (OptionData, error) {
+	if len(data.OptionChain.Result) == 0 {
+		return OptionData{}, ErrSymbolNotFound
+	}
+	if len(data.OptionChain.Result[0].Options) == 0 {
+		return OptionData{}, fmt.Errorf("%w: %s", ErrNoOptions, data.OptionChain.Result[0].UnderlyingSymbol)
+	}
+	date := time.Unix(data.OptionChain.Result[0].Options[0].ExpirationDate, 0).UTC().Format("2006-01-02")
 	var calls []OptionDetail
 	var puts []OptionDetail
 	for _, call := range data.OptionChain.Result[0].Options[0].Calls {
@@ -231,8 +271,8 @@ func (o *Option) transformData(data YahooOptionResponse) OptionData {
 			Bid:               call.Bid,
 			Ask:               call.Ask,
 			ContractSize:      call.ContractSize,
-			Expiration:        time.Unix(call.Expiration, 0).Format("2006-01-02"),
-			LastTradeDate:     time.Unix(call.LastTradeDate, 0).Format("2006-01-02"),
+			Expiration:        time.Unix(call.Expiration, 0).UTC().Format("2006-01-02"),
+			LastTradeDate:     time.Unix(call.LastTradeDate, 0).UTC().Format("2006-01-02"),
 			ImpliedVolatility: call.ImpliedVolatility,
 			InTheMoney:        call.InTheMoney,
 		})
@@ -250,8 +290,8 @@ func (o *Option) transformData(data YahooOptionResponse) OptionData {
 			Bid:               put.Bid,
 			Ask:               put.Ask,
 			ContractSize:      put.ContractSize,
-			Expiration:        time.Unix(put.Expiration, 0).Format("2006-01-02"),
-			LastTradeDate:     time.Unix(put.LastTradeDate, 0).Format("2006-01-02"),
+			Expiration:        time.Unix(put.Expiration, 0).UTC().Format("2006-01-02"),
+			LastTradeDate:     time.Unix(put.LastTradeDate, 0).UTC().Format("2006-01-02"),
 			ImpliedVolatility: put.ImpliedVolatility,
 			InTheMoney:        put.InTheMoney,
 		})
@@ -261,14 +301,26 @@ func (o *Option) transformData(data YahooOptionResponse) OptionData {
 		HasMiniOptions: data.OptionChain.Result[0].HasMiniOptions,
 		Calls:          calls,
 		Puts:           puts,
-	}
+	}, nil
+}
+
+func (o *Option) GetExpirationDates(symbol string) ([]string, error) {
+	return o.GetExpirationDatesContext(context.Background(), symbol)
 }
 
-func (o *Option) GetExpirationDates(symbol string) []string {
-	optionChain := o.GetOptionChain(symbol)
+func (o *Option) GetExpirationDatesContext(ctx context.Context, symbol string) ([]string, error) {
+	optionChain, err := o.fetchOptionChain(ctx, symbol, url.Values{})
+	if err != nil {
+		o.client.log().ErrorContext(ctx, "Failed to get expiration dates", "err", err)
+		return nil, err
+	}
+	dates := optionChain.OptionChain.Result[0].ExpirationDates
+	if len(dates) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNoOptions, symbol)
+	}
 	var expirationDates []string
-	for _, date := range optionChain.OptionChain.Result[0].ExpirationDates {
-		expirationDates = append(expirationDates, time.Unix(date, 0).Format("2006-01-02"))
+	for _, date := range dates {
+		expirationDates = append(expirationDates, time.Unix(date, 0).UTC().Format("2006-01-02"))
 	}
-	return expirationDates
+	return expirationDates, nil
 }
@@ -0,0 +1,72 @@
+package yahoofinanceapi_test
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	yahoofinanceapi "github.com/oscarli916/yahoo-finance-api"
+	"github.com/oscarli916/yahoo-finance-api/yahoofinancetest"
+)
+
+// TestMain runs the suite west of UTC, where formatting Yahoo's UTC-midnight
+// timestamps in local time lands on the previous day.
+func TestMain(m *testing.M) {
+	time.Local = time.FixedZone("UTC-5", -5*60*60)
+	os.Exit(m.Run())
+}
+
+func TestOptionErrors(t *testing.T) {
+	server := yahoofinancetest.NewServer()
+	defer server.Close()
+	expiration := time.Date(2024, 1, 19, 0, 0, 0, 0, time.UTC)
+	server.SetOptions("AAPL", yahoofinanceapi.YahooOptionResult{
+		Options: []yahoofinanceapi.YahooOptions{{ExpirationDate: expiration.Unix()}},
+	})
+	ticker := yahoofinanceapi.NewTickerWithClient("AAPL", server.NewClient(yahoofinanceapi.WithLogger(nil)))
+
+	dates, err := ticker.ExpirationDates()
+	if err != nil || len(dates) != 1 || dates[0] != "2024-01-19" {
+		t.Fatalf("ExpirationDates = %v, %v, want [2024-01-19]", dates, err)
+	}
+	if _, err := ticker.OptionChainByExpiration(dates[0]); err != nil {
+		t.Errorf("OptionChainByExpiration of a listed date: %v", err)
+	}
+	if _, err := ticker.OptionChainByExpiration("2024-01-26"); !errors.Is(err, yahoofinanceapi.ErrExpirationNotAvailable) {
+		t.Errorf("OptionChainByExpiration error = %v, want ErrExpirationNotAvailable", err)
+	}
+}
+
+func TestOptionChainDatesAreUTC(t *testing.T) {
+	server := yahoofinancetest.NewServer()
+	defer server.Close()
+	expiration := time.Date(2024, 1, 19, 0, 0, 0, 0, time.UTC)
+	lastTrade := time.Date(2024, 1, 12, 2, 0, 0, 0, time.UTC)
+	contract := yahoofinanceapi.YahooOption{
+		ContractSymbol: "AAPL240119C00190000",
+		Expiration:     expiration.Unix(),
+		LastTradeDate:  lastTrade.Unix(),
+	}
+	server.SetOptions("AAPL", yahoofinanceapi.YahooOptionResult{
+		Options: []yahoofinanceapi.YahooOptions{{
+			ExpirationDate: expiration.Unix(),
+			Calls:          []yahoofinanceapi.YahooOption{contract},
+			Puts:           []yahoofinanceapi.YahooOption{contract},
+		}},
+	})
+	ticker := yahoofinanceapi.NewTickerWithClient("AAPL", server.NewClient(yahoofinanceapi.WithLogger(nil)))
+
+	chain, err := ticker.OptionChain()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if chain.ExpirationDate != "2024-01-19" {
+		t.Errorf("ExpirationDate = %s, want 2024-01-19", chain.ExpirationDate)
+	}
+	for _, d := range append(chain.Calls, chain.Puts...) {
+		if d.Expiration != "2024-01-19" || d.LastTradeDate != "2024-01-12" {
+			t.Errorf("%s: Expiration = %s, LastTradeDate = %s, want 2024-01-19 and 2024-01-12", d.ContractSymbol, d.Expiration, d.LastTradeDate)
+		}
+	}
+}
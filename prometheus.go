@@ -0,0 +1,203 @@
+package yahoofinanceapi
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultLatencyBuckets are the upper bounds, in seconds, of the call
+// latency histogram of PrometheusObserver.
+var DefaultLatencyBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+// PrometheusObserver is an Observer that aggregates calls into counters
+// and a latency histogram and serves them in the Prometheus text format.
+// It is an http.Handler, so it can be mounted on any mux:
+//
+//	metrics := yahoofinanceapi.NewPrometheusObserver()
+//	client := yahoofinanceapi.NewClient(yahoofinanceapi.WithObserver(metrics))
+//	http.Handle("/metrics", metrics)
+type PrometheusObserver struct {
+	buckets []float64
+
+	mu        sync.Mutex
+	inFlight  map[string]int64
+	calls     map[[2]string]int64
+	latency   map[string]*histogram
+	bytes     map[string]int64
+	retries   map[string]int64
+	cacheHits map[string]int64
+	coalesced map[string]int64
+	hosts     map[string]int64
+	refreshes map[string]int64
+}
+
+type histogram struct {
+	counts []int64
+	sum    float64
+	count  int64
+}
+
+// NewPrometheusObserver returns an observer using DefaultLatencyBuckets.
+func NewPrometheusObserver() *PrometheusObserver {
+	return &PrometheusObserver{
+		buckets:   DefaultLatencyBuckets,
+		inFlight:  make(map[string]int64),
+		calls:     make(map[[2]string]int64),
+		latency:   make(map[string]*histogram),
+		bytes:     make(map[string]int64),
+		retries:   make(map[string]int64),
+		cacheHits: make(map[string]int64),
+		coalesced: make(map[string]int64),
+		hosts:     make(map[string]int64),
+		refreshes: make(map[string]int64),
+	}
+}
+
+func (p *PrometheusObserver) CallStarted(ctx context.Context, call CallInfo) context.Context {
+	p.mu.Lock()
+	p.inFlight[string(call.Endpoint)]++
+	p.mu.Unlock()
+	return ctx
+}
+
+func (p *PrometheusObserver) CallFinished(ctx context.Context, call CallInfo, result CallResult) {
+	e := string(call.Endpoint)
+	code := "error"
+	if result.StatusCode != 0 {
+		code = strconv.Itoa(result.StatusCode)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.inFlight[e]--
+	p.calls[[2]string{e, code}]++
+	p.bytes[e] += int64(result.Bytes)
+	p.retries[e] += int64(result.Retries)
+	if result.CacheHit {
+		p.cacheHits[e]++
+	}
+	if result.Coalesced {
+		p.coalesced[e]++
+	}
+	if result.Host != "" && !result.CacheHit && !result.Coalesced {
+		p.hosts[result.Host]++
+	}
+
+	h, ok := p.latency[e]
+	if !ok {
+		h = &histogram{counts: make([]int64, len(p.buckets))}
+		p.latency[e] = h
+	}
+	seconds := result.Latency.Seconds()
+	for i, bound := range p.buckets {
+		if seconds <= bound {
+			h.counts[i]++
+		}
+	}
+	h.sum += seconds
+	h.count++
+}
+
+func (p *PrometheusObserver) SessionRefreshed(ctx context.Context, event RotationEvent) {
+	p.mu.Lock()
+	p.refreshes[string(event.Reason)]++
+	p.mu.Unlock()
+}
+
+// ServeHTTP writes the current metrics in the Prometheus text format.
+func (p *PrometheusObserver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	p.WriteTo(w)
+}
+
+// WriteTo writes the current metrics in the Prometheus text format to w.
+func (p *PrometheusObserver) WriteTo(w io.Writer) (int64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	cw := &countingWriter{w: w}
+	bw := bufio.NewWriter(cw)
+	series(bw, "yahoofinance_calls_in_flight", "API calls currently running.", "gauge", "endpoint", p.inFlight)
+
+	header(bw, "yahoofinance_calls_total", "API calls by endpoint family and final status code.", "counter")
+	keys := make([][2]string, 0, len(p.calls))
+	for k := range p.calls {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i][0] != keys[j][0] {
+			return keys[i][0] < keys[j][0]
+		}
+		return keys[i][1] < keys[j][1]
+	})
+	for _, k := range keys {
+		fmt.Fprintf(bw, "yahoofinance_calls_total{endpoint=%s,code=%s} %d\n", labelValue(k[0]), labelValue(k[1]), p.calls[k])
+	}
+
+	header(bw, "yahoofinance_call_duration_seconds", "Latency of API calls including retries.", "histogram")
+	for _, e := range sortedKeys(p.latency) {
+		h := p.latency[e]
+		for i, bound := range p.buckets {
+			fmt.Fprintf(bw, "yahoofinance_call_duration_seconds_bucket{endpoint=%s,le=%s} %d\n", labelValue(e), labelValue(strconv.FormatFloat(bound, 'g', -1, 64)), h.counts[i])
+		}
+		fmt.Fprintf(bw, "yahoofinance_call_duration_seconds_bucket{endpoint=%s,le=\"+Inf\"} %d\n", labelValue(e), h.count)
+		fmt.Fprintf(bw, "yahoofinance_call_duration_seconds_sum{endpoint=%s} %s\n", labelValue(e), strconv.FormatFloat(h.sum, 'g', -1, 64))
+		fmt.Fprintf(bw, "yahoofinance_call_duration_seconds_count{endpoint=%s} %d\n", labelValue(e), h.count)
+	}
+
+	series(bw, "yahoofinance_response_bytes_total", "Bytes of response bodies returned to callers.", "counter", "endpoint", p.bytes)
+	series(bw, "yahoofinance_retries_total", "Attempts made after the first one.", "counter", "endpoint", p.retries)
+	series(bw, "yahoofinance_cache_hits_total", "Calls answered from the response cache.", "counter", "endpoint", p.cacheHits)
+	series(bw, "yahoofinance_coalesced_total", "Calls that shared an identical in-flight request.", "counter", "endpoint", p.coalesced)
+	series(bw, "yahoofinance_host_calls_total", "Calls served by each API host.", "counter", "host", p.hosts)
+	series(bw, "yahoofinance_session_refreshes_total", "Sessions dropped by rotation or rejection.", "counter", "reason", p.refreshes)
+
+	err := bw.Flush()
+	return cw.n, err
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(b []byte) (int, error) {
+	n, err := c.w.Write(b)
+	c.n += int64(n)
+	return n, err
+}
+
+func header(w io.Writer, name, help, kind string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+// series writes a metric with a single label.
+func series(w io.Writer, name, help, kind, label string, values map[string]int64) {
+	header(w, name, help, kind)
+	for _, k := range sortedKeys(values) {
+		fmt.Fprintf(w, "%s{%s=%s} %d\n", name, label, labelValue(k), values[k])
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// labelValue formats a label value.
+func labelValue(v string) string {
+	return `"` + labelEscaper.Replace(v) + `"`
+}
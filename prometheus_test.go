@@ -0,0 +1,121 @@
+package yahoofinanceapi_test
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	yahoofinanceapi "github.com/oscarli916/yahoo-finance-api"
+	"github.com/oscarli916/yahoo-finance-api/yahoofinancetest"
+)
+
+// scrape fetches the metrics served by handler and returns them by series,
+// e.g. `yahoofinance_calls_total{endpoint="quote",code="200"}`.
+func scrape(t *testing.T, handler http.Handler) map[string]float64 {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q, want the Prometheus text format", ct)
+	}
+
+	metrics := make(map[string]float64)
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		i := strings.LastIndexByte(line, ' ')
+		v, err := strconv.ParseFloat(line[i+1:], 64)
+		if err != nil {
+			t.Fatalf("metric line %q: %v", line, err)
+		}
+		metrics[line[:i]] = v
+	}
+	return metrics
+}
+
+func TestPrometheusObserver(t *testing.T) {
+	server := yahoofinancetest.NewServer()
+	defer server.Close()
+	server.SetQuote(yahoofinanceapi.StockQuote{Symbol: "AAPL", RegularMarketPrice: 190})
+	server.SetBars("AAPL", []yahoofinancetest.Bar{{Time: time.Date(2024, 1, 2, 14, 30, 0, 0, time.UTC), Close: 185}})
+	server.SetNoOptions("AAPL")
+
+	metrics := yahoofinanceapi.NewPrometheusObserver()
+	client := server.NewClient(
+		yahoofinanceapi.WithObserver(metrics),
+		yahoofinanceapi.WithTransport(slowTransport{delay: 40 * time.Millisecond}),
+		yahoofinanceapi.WithResponseCache(yahoofinanceapi.NewMemoryCache(10), yahoofinanceapi.DefaultCacheTTL),
+		yahoofinanceapi.WithRetryPolicy(fastRetries),
+		yahoofinanceapi.WithLogger(nil),
+	)
+	quote := yahoofinanceapi.NewQuoteWithClient(client)
+
+	// The first quote bootstraps the session upstream, the second one is
+	// answered from the cache.
+	for i := 0; i < 2; i++ {
+		if _, err := quote.GetQuote("AAPL"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Every attempt of the chart call fails.
+	server.Fail(yahoofinancetest.FailServerError, fastRetries.MaxAttempts)
+	if _, err := yahoofinanceapi.NewHistoryWithClient(client).GetHistory("AAPL"); err == nil {
+		t.Fatal("GetHistory succeeded, want 503")
+	}
+	// A revoked session is dropped and replaced within the options call.
+	server.RevokeSession()
+	yahoofinanceapi.NewOptionWithClient(client).GetExpirationDates("AAPL")
+	client.RotateSession()
+
+	got := scrape(t, metrics)
+	want := map[string]float64{
+		`yahoofinance_calls_in_flight{endpoint="quote"}`:                        0,
+		`yahoofinance_calls_total{endpoint="quote",code="200"}`:                 2,
+		`yahoofinance_calls_total{endpoint="chart",code="503"}`:                 1,
+		`yahoofinance_calls_total{endpoint="options",code="200"}`:               1,
+		`yahoofinance_retries_total{endpoint="chart"}`:                          float64(fastRetries.MaxAttempts - 1),
+		`yahoofinance_cache_hits_total{endpoint="quote"}`:                       1,
+		`yahoofinance_session_refreshes_total{reason="rejected"}`:               1,
+		`yahoofinance_session_refreshes_total{reason="manual"}`:                 1,
+		`yahoofinance_call_duration_seconds_count{endpoint="quote"}`:            2,
+		`yahoofinance_call_duration_seconds_bucket{endpoint="quote",le="+Inf"}`: 2,
+		// The cache hit returns at once; the upstream call waits for the
+		// cookie, the crumb and the quote, 40ms each.
+		`yahoofinance_call_duration_seconds_bucket{endpoint="quote",le="0.05"}`: 1,
+		`yahoofinance_call_duration_seconds_bucket{endpoint="quote",le="0.1"}`:  1,
+	}
+	for series, v := range want {
+		if got[series] != v {
+			t.Errorf("%s = %v, want %v", series, got[series], v)
+		}
+	}
+	if n := got[`yahoofinance_call_duration_seconds_sum{endpoint="quote"}`]; n < 0.12 {
+		t.Errorf("quote latency sum = %vs, want at least 0.12s", n)
+	}
+
+	// Buckets are cumulative.
+	var prev float64
+	for _, bound := range yahoofinanceapi.DefaultLatencyBuckets {
+		series := `yahoofinance_call_duration_seconds_bucket{endpoint="chart",le="` + strconv.FormatFloat(bound, 'g', -1, 64) + `"}`
+		n, ok := got[series]
+		if !ok || n < prev {
+			t.Errorf("%s = %v, want a count of at least %v", series, n, prev)
+		}
+		prev = n
+	}
+	if n := got[`yahoofinance_call_duration_seconds_bucket{endpoint="chart",le="+Inf"}`]; n != 1 || prev != 1 {
+		t.Errorf("chart buckets end at %v and +Inf = %v, want 1", prev, n)
+	}
+}
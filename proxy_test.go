@@ -0,0 +1,171 @@
+package yahoofinanceapi_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	yahoofinanceapi "github.com/oscarli916/yahoo-finance-api"
+	"github.com/oscarli916/yahoo-finance-api/yahoofinancetest"
+)
+
+// testProxy is a forward proxy that counts the requests it relays by path
+// and can be told to throttle or break them.
+type testProxy struct {
+	*httptest.Server
+	throttle atomic.Int64 // requests left to answer with 429
+	drop     atomic.Int64 // requests left to answer with a broken response
+
+	mu    sync.Mutex
+	paths map[string]int
+}
+
+func newTestProxy() *testProxy {
+	p := &testProxy{paths: make(map[string]int)}
+	forward := &httputil.ReverseProxy{Director: func(*http.Request) {}}
+	p.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p.mu.Lock()
+		p.paths[r.URL.Path]++
+		p.mu.Unlock()
+		switch {
+		case p.throttle.Add(-1) >= 0:
+			w.WriteHeader(http.StatusTooManyRequests)
+		case p.drop.Add(-1) >= 0:
+			// Garbage instead of a status line fails the request at
+			// once; a bare close would be retried by http.Transport.
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			conn.Write([]byte("garbage\r\n\r\n"))
+			conn.Close()
+		default:
+			forward.ServeHTTP(w, r)
+		}
+	}))
+	return p
+}
+
+func (p *testProxy) requests(path string) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.paths[path]
+}
+
+func proxyClient(server *yahoofinancetest.Server, strategy yahoofinanceapi.ProxyStrategy, proxies ...*testProxy) *yahoofinanceapi.Client {
+	pool := yahoofinanceapi.ProxyPool{Strategy: strategy, Cooldown: 100 * time.Millisecond}
+	for _, p := range proxies {
+		pool.Proxies = append(pool.Proxies, p.URL)
+	}
+	return server.NewClient(
+		yahoofinanceapi.WithProxyPool(pool),
+		yahoofinanceapi.WithRetryPolicy(fastRetries),
+		yahoofinanceapi.WithLogger(nil),
+	)
+}
+
+func getQuotesThrough(t *testing.T, quote *yahoofinanceapi.Quote, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		if _, err := quote.GetQuote("AAPL"); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestProxyRoundRobin(t *testing.T) {
+	server := yahoofinancetest.NewServer()
+	defer server.Close()
+	server.SetQuote(yahoofinanceapi.StockQuote{Symbol: "AAPL", RegularMarketPrice: 190})
+	a, b := newTestProxy(), newTestProxy()
+	defer a.Close()
+	defer b.Close()
+	client := proxyClient(server, yahoofinanceapi.ProxyRoundRobin, a, b)
+	quote := yahoofinanceapi.NewQuoteWithClient(client)
+
+	getQuotesThrough(t, quote, 4)
+	for name, p := range map[string]*testProxy{"a": a, "b": b} {
+		if n := p.requests("/v7/finance/quote"); n != 2 {
+			t.Errorf("proxy %s relayed %d quotes, want 2", name, n)
+		}
+		// Each route bootstraps and keeps its own session.
+		if n := p.requests("/cookie"); n != 1 {
+			t.Errorf("proxy %s relayed %d cookie requests, want 1", name, n)
+		}
+		if n := p.requests("/v1/test/getcrumb"); n != 1 {
+			t.Errorf("proxy %s relayed %d crumb requests, want 1", name, n)
+		}
+	}
+
+	// A connection error benches a; its attempt is retried through b and
+	// b serves everything until the cool-down is over.
+	a.drop.Store(1)
+	getQuotesThrough(t, quote, 4)
+	if n := a.requests("/v7/finance/quote"); n != 3 {
+		t.Errorf("benched proxy relayed %d quotes, want only the dropped one", n-2)
+	}
+	if n := b.requests("/v7/finance/quote"); n != 6 {
+		t.Errorf("proxy b relayed %d quotes, want 6", n)
+	}
+	status := client.Proxies()
+	if !status[0].Benched || status[0].Errors != 1 || status[1].Benched {
+		t.Errorf("Proxies = %+v, want only the first one benched with 1 error", status)
+	}
+
+	time.Sleep(150 * time.Millisecond)
+	getQuotesThrough(t, quote, 4)
+	if n := a.requests("/v7/finance/quote"); n != 5 {
+		t.Errorf("proxy a relayed %d quotes after the cool-down, want 2", n-3)
+	}
+	if client.Proxies()[0].Benched {
+		t.Error("proxy a is still benched after the cool-down")
+	}
+	for name, p := range map[string]*testProxy{"a": a, "b": b} {
+		if n := p.requests("/cookie"); n != 1 {
+			t.Errorf("proxy %s relayed %d cookie requests, want the session to survive the bench", name, n)
+		}
+	}
+}
+
+func TestProxyLeastRecentlyThrottled(t *testing.T) {
+	server := yahoofinancetest.NewServer()
+	defer server.Close()
+	server.SetQuote(yahoofinanceapi.StockQuote{Symbol: "AAPL", RegularMarketPrice: 190})
+	a, b := newTestProxy(), newTestProxy()
+	defer a.Close()
+	defer b.Close()
+	client := proxyClient(server, yahoofinanceapi.ProxyLeastRecentlyThrottled, a, b)
+	quote := yahoofinanceapi.NewQuoteWithClient(client)
+
+	// Warm up both routes, then have b throttle its next request.
+	getQuotesThrough(t, quote, 2)
+	b.throttle.Store(1)
+	getQuotesThrough(t, quote, 2)
+	if status := client.Proxies(); !status[1].Benched || status[1].Throttled != 1 {
+		t.Fatalf("Proxies = %+v, want the second one benched after a 429", status)
+	}
+
+	// Once b is back, a is still preferred: it was never throttled.
+	time.Sleep(150 * time.Millisecond)
+	before := b.requests("/v7/finance/quote")
+	getQuotesThrough(t, quote, 4)
+	if n := b.requests("/v7/finance/quote") - before; n != 0 {
+		t.Errorf("proxy b relayed %d quotes, want a to be preferred", n)
+	}
+
+	// Now a's 429 is the most recent one, so b takes over for good.
+	a.throttle.Store(1)
+	getQuotesThrough(t, quote, 1)
+	time.Sleep(150 * time.Millisecond)
+	before = a.requests("/v7/finance/quote")
+	getQuotesThrough(t, quote, 4)
+	if n := a.requests("/v7/finance/quote") - before; n != 0 {
+		t.Errorf("proxy a relayed %d quotes, want b to be preferred", n)
+	}
+	for name, p := range map[string]*testProxy{"a": a, "b": b} {
+		if n := p.requests("/cookie"); n != 1 {
+			t.Errorf("proxy %s relayed %d cookie requests, want 1", name, n)
+		}
+	}
+}
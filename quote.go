@@ -1,9 +1,9 @@
 package yahoofinanceapi
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"log/slog"
 	"net/url"
 	"strings"
 )
@@ -28,7 +28,7 @@ type QuoteResponse struct {
 // QuoteResponseData는 quote 응답의 메인 데이터를 담는 구조체입니다.
 type QuoteResponseData struct {
 	Result []StockQuote `json:"result"`
-	Error  interface{}  `json:"error"`
+	Error  *YahooError  `json:"error"`
 }
 
 // StockQuote는 일반 주식의 실시간 quote 정보를 담는 구조체입니다.
@@ -142,7 +142,12 @@ type Quote struct {
 
 // NewQuote는 새로운 Quote 인스턴스를 생성합니다.
 func NewQuote() *Quote {
-	return &Quote{client: GetClient()}
+	return NewQuoteWithClient(GetClient())
+}
+
+// NewQuoteWithClient는 지정한 Client를 사용하는 Quote 인스턴스를 생성합니다.
+func NewQuoteWithClient(client *Client) *Quote {
+	return &Quote{client: client}
 }
 
 // GetQuote는 단일 심볼의 실시간 quote 정보를 조회합니다.
@@ -154,25 +159,34 @@ func NewQuote() *Quote {
 // - StockQuote: 주식의 실시간 quote 정보가 담긴 구조체
 // - error: 조회 중 발생한 오류
 func (q *Quote) GetQuote(symbol string) (StockQuote, error) {
-	endpoint := fmt.Sprintf("%s/v7/finance/quote", BASE_URL)
+	return q.GetQuoteContext(context.Background(), symbol)
+}
+
+// GetQuoteContext는 ctx를 사용하여 GetQuote를 수행합니다.
+// ctx가 취소되면 진행 중인 요청도 함께 취소됩니다.
+func (q *Quote) GetQuoteContext(ctx context.Context, symbol string) (StockQuote, error) {
+	endpoint := fmt.Sprintf("%s/v7/finance/quote", q.client.baseURL)
 	params := url.Values{}
 	params.Add("symbols", symbol)
 
-	resp, err := q.client.Get(endpoint, params)
+	resp, err := q.client.GetContext(ctx, endpoint, params)
 	if err != nil {
-		slog.Error("Failed to get quote data", "symbol", symbol, "err", err)
+		q.client.log().ErrorContext(ctx, "Failed to get quote data", "symbol", symbol, "err", err)
 		return StockQuote{}, err
 	}
 	defer resp.Body.Close()
 
 	var quoteResponse QuoteResponse
 	if err := json.NewDecoder(resp.Body).Decode(&quoteResponse); err != nil {
-		slog.Error("Failed to decode quote JSON response", "err", err)
+		q.client.log().ErrorContext(ctx, "Failed to decode quote JSON response", "err", err)
 		return StockQuote{}, err
 	}
 
+	if quoteResponse.QuoteResponse.Error != nil {
+		return StockQuote{}, quoteResponse.QuoteResponse.Error.apiError(EndpointQuote, resp.StatusCode)
+	}
 	if len(quoteResponse.QuoteResponse.Result) == 0 {
-		return StockQuote{}, fmt.Errorf("no data found for symbol: %s", symbol)
+		return StockQuote{}, fmt.Errorf("%w: %s", ErrSymbolNotFound, symbol)
 	}
 
 	return quoteResponse.QuoteResponse.Result[0], nil
@@ -187,29 +201,37 @@ func (q *Quote) GetQuote(symbol string) (StockQuote, error) {
 // - []StockQuote: 각 심볼의 quote 정보가 담긴 구조체 슬라이스
 // - error: 조회 중 발생한 오류
 func (q *Quote) GetMultipleQuotes(symbols []string) ([]StockQuote, error) {
+	return q.GetMultipleQuotesContext(context.Background(), symbols)
+}
+
+// GetMultipleQuotesContext는 ctx를 사용하여 GetMultipleQuotes를 수행합니다.
+func (q *Quote) GetMultipleQuotesContext(ctx context.Context, symbols []string) ([]StockQuote, error) {
 	if len(symbols) == 0 {
-		return []StockQuote{}, fmt.Errorf("no symbols provided")
+		return []StockQuote{}, fmt.Errorf("%w: no symbols provided", ErrInvalidQuery)
 	}
 
-	endpoint := fmt.Sprintf("%s/v7/finance/quote", BASE_URL)
+	endpoint := fmt.Sprintf("%s/v7/finance/quote", q.client.baseURL)
 	params := url.Values{}
 
 	// 여러 심볼을 콤마로 구분하여 전달
 	symbolsStr := strings.Join(symbols, ",")
 	params.Add("symbols", symbolsStr)
 
-	resp, err := q.client.Get(endpoint, params)
+	resp, err := q.client.GetContext(ctx, endpoint, params)
 	if err != nil {
-		slog.Error("Failed to get multiple quote data", "symbols", symbols, "err", err)
+		q.client.log().ErrorContext(ctx, "Failed to get multiple quote data", "symbols", symbols, "err", err)
 		return []StockQuote{}, err
 	}
 	defer resp.Body.Close()
 
 	var quoteResponse QuoteResponse
 	if err := json.NewDecoder(resp.Body).Decode(&quoteResponse); err != nil {
-		slog.Error("Failed to decode multiple quote JSON response", "err", err)
+		q.client.log().ErrorContext(ctx, "Failed to decode multiple quote JSON response", "err", err)
 		return []StockQuote{}, err
 	}
+	if quoteResponse.QuoteResponse.Error != nil {
+		return []StockQuote{}, quoteResponse.QuoteResponse.Error.apiError(EndpointQuote, resp.StatusCode)
+	}
 
 	return quoteResponse.QuoteResponse.Result, nil
 }
@@ -223,7 +245,12 @@ func (q *Quote) GetMultipleQuotes(symbols []string) ([]StockQuote, error) {
 // - MarketCapInfo: 시가총액 정보가 담긴 구조체
 // - error: 조회 중 발생한 오류
 func (q *Quote) GetMarketCap(symbol string) (MarketCapInfo, error) {
-	quote, err := q.GetQuote(symbol)
+	return q.GetMarketCapContext(context.Background(), symbol)
+}
+
+// GetMarketCapContext는 ctx를 사용하여 GetMarketCap을 수행합니다.
+func (q *Quote) GetMarketCapContext(ctx context.Context, symbol string) (MarketCapInfo, error) {
+	quote, err := q.GetQuoteContext(ctx, symbol)
 	if err != nil {
 		return MarketCapInfo{}, err
 	}
@@ -240,7 +267,12 @@ func (q *Quote) GetMarketCap(symbol string) (MarketCapInfo, error) {
 // - []MarketCapInfo: 각 심볼의 시가총액 정보가 담긴 구조체 슬라이스
 // - error: 조회 중 발생한 오류
 func (q *Quote) GetMultipleMarketCaps(symbols []string) ([]MarketCapInfo, error) {
-	quotes, err := q.GetMultipleQuotes(symbols)
+	return q.GetMultipleMarketCapsContext(context.Background(), symbols)
+}
+
+// GetMultipleMarketCapsContext는 ctx를 사용하여 GetMultipleMarketCaps를 수행합니다.
+func (q *Quote) GetMultipleMarketCapsContext(ctx context.Context, symbols []string) ([]MarketCapInfo, error) {
+	quotes, err := q.GetMultipleQuotesContext(ctx, symbols)
 	if err != nil {
 		return []MarketCapInfo{}, err
 	}
@@ -317,8 +349,13 @@ func (q *Quote) formatMarketCap(marketCap int64) string {
 // - []MarketCapInfo: 각 심볼의 시가총액 정보가 담긴 구조체 슬라이스
 // - error: 조회 중 발생한 오류
 func GetMultipleMarketCaps(symbols []string) ([]MarketCapInfo, error) {
+	return GetMultipleMarketCapsContext(context.Background(), symbols)
+}
+
+// GetMultipleMarketCapsContext는 ctx를 사용하여 GetMultipleMarketCaps를 수행하는 편의 함수입니다.
+func GetMultipleMarketCapsContext(ctx context.Context, symbols []string) ([]MarketCapInfo, error) {
 	quote := NewQuote()
-	return quote.GetMultipleMarketCaps(symbols)
+	return quote.GetMultipleMarketCapsContext(ctx, symbols)
 }
 
 // GetMarketCapGlobal은 패키지 레벨에서 단일 심볼의 시가총액을 조회하는 편의 함수입니다.
@@ -330,6 +367,11 @@ func GetMultipleMarketCaps(symbols []string) ([]MarketCapInfo, error) {
 // - MarketCapInfo: 시가총액 정보가 담긴 구조체
 // - error: 조회 중 발생한 오류
 func GetMarketCap(symbol string) (MarketCapInfo, error) {
+	return GetMarketCapContext(context.Background(), symbol)
+}
+
+// GetMarketCapContext는 ctx를 사용하여 GetMarketCap을 수행하는 편의 함수입니다.
+func GetMarketCapContext(ctx context.Context, symbol string) (MarketCapInfo, error) {
 	quote := NewQuote()
-	return quote.GetMarketCap(symbol)
+	return quote.GetMarketCapContext(ctx, symbol)
 }
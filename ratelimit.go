@@ -0,0 +1,177 @@
+package yahoofinanceapi
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Endpoint names a family of Yahoo Finance endpoints. Rate limits and
+// other per-endpoint settings are keyed by it.
+type Endpoint string
+
+const (
+	EndpointChart   Endpoint = "chart"
+	EndpointQuote   Endpoint = "quote"
+	EndpointOptions Endpoint = "options"
+	EndpointCrumb   Endpoint = "crumb"
+	EndpointCookie  Endpoint = "cookie"
+	EndpointOther   Endpoint = "other"
+)
+
+// endpointOf classifies a request URL into its endpoint family.
+func (c *Client) endpointOf(rawURL string) Endpoint {
+	if rawURL == c.cookieURL {
+		return EndpointCookie
+	}
+	if rawURL == c.crumbURL {
+		return EndpointCrumb
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return EndpointOther
+	}
+	switch {
+	case strings.Contains(u.Path, "/finance/chart/"):
+		return EndpointChart
+	case strings.HasSuffix(u.Path, "/finance/quote"):
+		return EndpointQuote
+	case strings.Contains(u.Path, "/finance/options/"):
+		return EndpointOptions
+	case strings.HasSuffix(u.Path, "/getcrumb"):
+		return EndpointCrumb
+	}
+	return EndpointOther
+}
+
+// RateLimit describes a token bucket: RequestsPerSecond tokens are added
+// every second, up to Burst tokens.
+type RateLimit struct {
+	RequestsPerSecond float64
+	Burst             int
+}
+
+// WithRateLimit throttles every endpoint family that has no limit of its
+// own set through WithEndpointRateLimit.
+func WithRateLimit(l RateLimit) ClientOption {
+	return func(c *Client) {
+		c.defaultLimit = &l
+	}
+}
+
+// WithEndpointRateLimit throttles a single endpoint family.
+func WithEndpointRateLimit(e Endpoint, l RateLimit) ClientOption {
+	return func(c *Client) {
+		if c.endpointLimits == nil {
+			c.endpointLimits = make(map[Endpoint]RateLimit)
+		}
+		c.endpointLimits[e] = l
+	}
+}
+
+// QueueDepth reports how many calls are currently waiting on the rate
+// limiter of each endpoint family.
+func (c *Client) QueueDepth() map[Endpoint]int {
+	c.limitersMu.Lock()
+	defer c.limitersMu.Unlock()
+	depth := make(map[Endpoint]int, len(c.limiters))
+	for e, l := range c.limiters {
+		depth[e] = int(l.waiting.Load())
+	}
+	return depth
+}
+
+// waitRateLimit blocks until the endpoint family has a free token.
+func (c *Client) waitRateLimit(ctx context.Context, e Endpoint) error {
+	l := c.limiter(e)
+	if l == nil {
+		return nil
+	}
+	return l.wait(ctx)
+}
+
+// limiter returns the bucket for e, creating it on first use. It returns
+// nil when e is not limited.
+func (c *Client) limiter(e Endpoint) *tokenBucket {
+	c.limitersMu.Lock()
+	defer c.limitersMu.Unlock()
+	if l, ok := c.limiters[e]; ok {
+		return l
+	}
+	limit, ok := c.endpointLimits[e]
+	if !ok {
+		if c.defaultLimit == nil {
+			return nil
+		}
+		limit = *c.defaultLimit
+	}
+	if limit.RequestsPerSecond <= 0 {
+		return nil
+	}
+	if c.limiters == nil {
+		c.limiters = make(map[Endpoint]*tokenBucket)
+	}
+	l := newTokenBucket(limit)
+	c.limiters[e] = l
+	return l
+}
+
+type tokenBucket struct {
+	mu      sync.Mutex
+	rate    float64
+	burst   float64
+	tokens  float64
+	last    time.Time
+	waiting atomic.Int64
+}
+
+func newTokenBucket(l RateLimit) *tokenBucket {
+	burst := float64(l.Burst)
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{rate: l.RequestsPerSecond, burst: burst, tokens: burst, last: time.Now()}
+}
+
+// reserve takes a token and returns how long the caller has to wait
+// before using it. Tokens may go negative, which queues callers in
+// arrival order.
+func (b *tokenBucket) reserve() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// cancel hands back a token reserved by a caller that gave up waiting.
+func (b *tokenBucket) cancel() {
+	b.mu.Lock()
+	b.tokens++
+	b.mu.Unlock()
+}
+
+func (b *tokenBucket) wait(ctx context.Context) error {
+	d := b.reserve()
+	if d == 0 {
+		return nil
+	}
+	b.waiting.Add(1)
+	defer b.waiting.Add(-1)
+	if err := sleep(ctx, d); err != nil {
+		b.cancel()
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package yahoofinanceapi_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	yahoofinanceapi "github.com/oscarli916/yahoo-finance-api"
+	"github.com/oscarli916/yahoo-finance-api/yahoofinancetest"
+)
+
+func TestEndpointRateLimit(t *testing.T) {
+	server := yahoofinancetest.NewServer()
+	defer server.Close()
+	server.SetQuote(yahoofinanceapi.StockQuote{Symbol: "AAPL", RegularMarketPrice: 190})
+	limit := yahoofinanceapi.RateLimit{RequestsPerSecond: 20, Burst: 1}
+	const calls = 6
+	client := server.NewClient(
+		yahoofinanceapi.WithEndpointRateLimit(yahoofinanceapi.EndpointQuote, limit),
+		yahoofinanceapi.WithCoalescing(false),
+	)
+	quote := yahoofinanceapi.NewQuoteWithClient(client)
+	if _, err := quote.GetQuote("AAPL"); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < calls; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := quote.GetQuote("AAPL"); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	if depth := client.QueueDepth()[yahoofinanceapi.EndpointQuote]; depth == 0 {
+		t.Error("no quote call is waiting for the rate limiter")
+	}
+	wg.Wait()
+
+	// The warm-up call used up the burst, so the calls are spaced one
+	// interval apart. The first one may find its token partly refilled.
+	interval := time.Duration(float64(time.Second) / limit.RequestsPerSecond)
+	if elapsed, want := time.Since(start), (calls-1)*interval; elapsed < want {
+		t.Errorf("%d calls took %v, want at least %v at %v per second", calls, elapsed, want, limit.RequestsPerSecond)
+	}
+	if n := quoteRequests(server); n != calls+1 {
+		t.Errorf("server got %d quote requests, want %d", n, calls+1)
+	}
+}
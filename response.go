@@ -0,0 +1,113 @@
+package yahoofinanceapi
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"unicode"
+)
+
+// DefaultMaxResponseBytes caps how much of a response body Client reads.
+// Intraday charts with pre/post market data stay far below it.
+const DefaultMaxResponseBytes int64 = 32 << 20
+
+// WithMaxResponseBytes changes DefaultMaxResponseBytes for this client.
+func WithMaxResponseBytes(n int64) ClientOption {
+	return func(c *Client) {
+		if n > 0 {
+			c.maxResponseBytes = n
+		}
+	}
+}
+
+// bufferedBody is a response body that was read into memory. Its bytes
+// stay accessible so the response can be inspected without consuming it.
+type bufferedBody struct {
+	*bytes.Reader
+	data []byte
+}
+
+func (bufferedBody) Close() error { return nil }
+
+// bodyBytes returns the buffered body of resp, or nil if it is not buffered.
+func bodyBytes(resp *http.Response) []byte {
+	if b, ok := resp.Body.(*bufferedBody); ok {
+		return b.data
+	}
+	return nil
+}
+
+// bufferBody reads resp.Body up to the client's size limit and replaces it
+// with an in-memory copy.
+func (c *Client) bufferBody(resp *http.Response) error {
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, c.maxResponseBytes+1))
+	if err != nil {
+		return err
+	}
+	if int64(len(data)) > c.maxResponseBytes {
+		return fmt.Errorf("%w: more than %d bytes", ErrResponseTooLarge, c.maxResponseBytes)
+	}
+	resp.Body = &bufferedBody{Reader: bytes.NewReader(data), data: data}
+	resp.ContentLength = int64(len(data))
+	return nil
+}
+
+// checkContent verifies that a 2xx response carries what endpoint e is
+// expected to return. HTML pages, which Yahoo serves for consent walls
+// and other interstitials, are always rejected.
+func checkContent(e Endpoint, resp *http.Response) error {
+	if e == EndpointCookie {
+		return nil
+	}
+	body := bytes.TrimSpace(bodyBytes(resp))
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+
+	if mediaType == "text/html" || bytes.HasPrefix(body, []byte("<")) {
+		if isConsentPage(resp, body) {
+			return ErrConsentRequired
+		}
+		return fmt.Errorf("%w: %s served an HTML page", ErrUnexpectedContent, e)
+	}
+	if e == EndpointCrumb {
+		return nil
+	}
+	if len(body) == 0 {
+		return fmt.Errorf("%w: %s returned an empty body", ErrUnexpectedContent, e)
+	}
+	// Not every server labels JSON correctly, so the body decides.
+	if body[0] != '{' && body[0] != '[' {
+		return fmt.Errorf("%w: %s returned %q instead of JSON", ErrUnexpectedContent, e, mediaType)
+	}
+	return nil
+}
+
+// isConsentPage recognizes Yahoo's GUCE consent wall.
+func isConsentPage(resp *http.Response, body []byte) bool {
+	if resp.Request != nil {
+		host := resp.Request.URL.Hostname()
+		if strings.HasPrefix(host, "guce.") || strings.HasPrefix(host, "consent.") {
+			return true
+		}
+	}
+	return bytes.Contains(body, []byte("consent.yahoo.com")) ||
+		bytes.Contains(body, []byte("guce.yahoo.com")) ||
+		findConsentForm(resp) != nil
+}
+
+// validCrumb rejects bodies that cannot be a crumb, such as error
+// messages or markup returned with a 200 status.
+func validCrumb(crumb string) bool {
+	if len(crumb) < 4 || len(crumb) > 64 {
+		return false
+	}
+	for _, r := range crumb {
+		if unicode.IsSpace(r) || unicode.IsControl(r) || strings.ContainsRune(`<>{}"`, r) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,75 @@
+package yahoofinanceapi_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	yahoofinanceapi "github.com/oscarli916/yahoo-finance-api"
+	"github.com/oscarli916/yahoo-finance-api/yahoofinancetest"
+)
+
+var fastRetries = yahoofinanceapi.RetryPolicy{MaxAttempts: 3, BaseBackoff: time.Millisecond}
+
+func TestRetryRecoversFromServerErrors(t *testing.T) {
+	server := yahoofinancetest.NewServer()
+	defer server.Close()
+	server.SetQuote(yahoofinanceapi.StockQuote{Symbol: "AAPL", RegularMarketPrice: 190})
+	client := server.NewClient(yahoofinanceapi.WithRetryPolicy(fastRetries), yahoofinanceapi.WithLogger(nil))
+
+	server.Fail(yahoofinancetest.FailServerError, 2)
+	q, err := yahoofinanceapi.NewQuoteWithClient(client).GetQuote("AAPL")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q.RegularMarketPrice != 190 {
+		t.Errorf("price = %v, want 190", q.RegularMarketPrice)
+	}
+	if n := quoteRequests(server); n != 3 {
+		t.Errorf("server got %d quote requests, want 3", n)
+	}
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	server := yahoofinancetest.NewServer()
+	defer server.Close()
+	server.SetQuote(yahoofinanceapi.StockQuote{Symbol: "AAPL", RegularMarketPrice: 190})
+	client := server.NewClient(yahoofinanceapi.WithRetryPolicy(fastRetries), yahoofinanceapi.WithLogger(nil))
+
+	server.Fail(yahoofinancetest.FailRateLimit, -1)
+	_, err := yahoofinanceapi.NewQuoteWithClient(client).GetQuote("AAPL")
+	var apiErr *yahoofinanceapi.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("GetQuote error = %v, want a 429 *APIError", err)
+	}
+	if !errors.Is(err, yahoofinanceapi.ErrRateLimited) {
+		t.Errorf("%v does not match ErrRateLimited", err)
+	}
+	if n := quoteRequests(server); n != fastRetries.MaxAttempts {
+		t.Errorf("server got %d quote requests, want %d", n, fastRetries.MaxAttempts)
+	}
+}
+
+func TestRetryStopsWhenCanceled(t *testing.T) {
+	server := yahoofinancetest.NewServer()
+	defer server.Close()
+	server.SetQuote(yahoofinanceapi.StockQuote{Symbol: "AAPL", RegularMarketPrice: 190})
+	client := server.NewClient(
+		yahoofinanceapi.WithRetryPolicy(yahoofinanceapi.RetryPolicy{MaxAttempts: 5, BaseBackoff: time.Hour}),
+		yahoofinanceapi.WithLogger(nil),
+	)
+
+	server.Fail(yahoofinancetest.FailServerError, -1)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := yahoofinanceapi.NewQuoteWithClient(client).GetQuoteContext(ctx, "AAPL")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("GetQuote error = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("GetQuote returned after %v, want it to stop waiting at the deadline", elapsed)
+	}
+}
@@ -0,0 +1,157 @@
+package yahoofinanceapi
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// RotationPolicy decides when a session is replaced by a fresh
+// cookie/crumb pair. Zero fields disable the corresponding trigger.
+type RotationPolicy struct {
+	// EveryNCalls rotates after this many API calls on one session.
+	// Retries and the replay after a rejected session are part of the
+	// call they belong to.
+	EveryNCalls int64
+	// MaxAge rotates sessions older than this.
+	MaxAge time.Duration
+	// UnauthorizedStreak rotates after this many consecutive 401/403
+	// responses.
+	UnauthorizedStreak int
+	// RateLimitedStreak rotates after this many consecutive 429 responses.
+	RateLimitedStreak int
+}
+
+// DefaultRotationPolicy keeps the historical behaviour of rotating every
+// RotateThreshold calls.
+var DefaultRotationPolicy = RotationPolicy{EveryNCalls: RotateThreshold}
+
+// WithRotationPolicy replaces DefaultRotationPolicy for this client.
+func WithRotationPolicy(p RotationPolicy) ClientOption {
+	return func(c *Client) {
+		c.rotation = p
+	}
+}
+
+// RotationReason tells why a session was rotated.
+type RotationReason string
+
+const (
+	RotationCallCount    RotationReason = "call_count"
+	RotationAge          RotationReason = "age"
+	RotationUnauthorized RotationReason = "unauthorized"
+	RotationRateLimited  RotationReason = "rate_limited"
+	RotationManual       RotationReason = "manual"
+)
+
+// RotationEvent is passed to the hooks set with WithRotationHooks.
+type RotationEvent struct {
+	Reason RotationReason
+	// Route is the proxy the session belongs to, or "direct".
+	Route     string
+	SessionID string
+	// Calls is the number of API calls made since the session started.
+	Calls int64
+	Age   time.Duration
+}
+
+// WithRotationHooks registers functions called when a session is
+// rotated: before once the session has been dropped, ahead of the
+// cleanup of its counters and stored copy, and after once that is done.
+// When several callers rotate the same session only one of them runs the
+// hooks. The replacement session is bootstrapped by the next request.
+// Either hook may be nil.
+func WithRotationHooks(before, after func(RotationEvent)) ClientOption {
+	return func(c *Client) {
+		c.beforeRotate = before
+		c.afterRotate = after
+	}
+}
+
+// RotateSession drops the current session of every route, so the next
+// request bootstraps new cookies and a new crumb.
+func (c *Client) RotateSession() {
+	ctx := context.Background()
+	for _, r := range c.routes {
+		if s := r.sessions.peek(); s != nil {
+			c.rotate(ctx, r, s, RotationManual)
+		}
+	}
+}
+
+// maybeRotateSession rotates the session of r when the call count or
+// the age limit of the policy is reached. newCall is true for the first
+// attempt of an API call, which is counted on r; retries and replays are
+// not. It reports whether the session was rotated.
+func (c *Client) maybeRotateSession(ctx context.Context, r *route, newCall bool) bool {
+	calls := r.calls.Load()
+	if newCall {
+		calls = r.calls.Add(1)
+	}
+	s := r.sessions.peek()
+	if s == nil {
+		return false
+	}
+	switch {
+	case c.rotation.EveryNCalls > 0 && calls >= c.rotation.EveryNCalls:
+		return c.rotate(ctx, r, s, RotationCallCount)
+	case c.rotation.MaxAge > 0 && time.Since(s.created) > c.rotation.MaxAge:
+		return c.rotate(ctx, r, s, RotationAge)
+	}
+	return false
+}
+
+// trackStreaks rotates the session of r after too many consecutive
+// 401/403 or 429 responses. It reports whether the session was rotated.
+func (c *Client) trackStreaks(ctx context.Context, r *route, s *session, resp *http.Response, err error) bool {
+	if err != nil {
+		return false
+	}
+	switch resp.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		r.rateLimitedStreak.Store(0)
+		n := r.unauthorizedStreak.Add(1)
+		if c.rotation.UnauthorizedStreak > 0 && n >= int64(c.rotation.UnauthorizedStreak) {
+			return c.rotate(ctx, r, s, RotationUnauthorized)
+		}
+	case http.StatusTooManyRequests:
+		r.unauthorizedStreak.Store(0)
+		n := r.rateLimitedStreak.Add(1)
+		if c.rotation.RateLimitedStreak > 0 && n >= int64(c.rotation.RateLimitedStreak) {
+			return c.rotate(ctx, r, s, RotationRateLimited)
+		}
+	default:
+		r.unauthorizedStreak.Store(0)
+		r.rateLimitedStreak.Store(0)
+	}
+	return false
+}
+
+// rotate drops s from r if it is still current and fires the hooks.
+// Concurrent callers that saw the same session rotate it only once, and
+// only that one runs the hooks and gets true.
+func (c *Client) rotate(ctx context.Context, r *route, s *session, reason RotationReason) bool {
+	if !r.sessions.invalidate(s) {
+		return false
+	}
+	event := refreshEvent(r, s, reason)
+	if c.beforeRotate != nil {
+		c.beforeRotate(event)
+	}
+	r.resetCounters()
+	c.clearStoredSession(ctx, r)
+	c.log().DebugContext(ctx, "rotated Yahoo Finance session", "route", r.name, "reason", reason, "calls", event.Calls, "age", event.Age)
+	if c.afterRotate != nil {
+		c.afterRotate(event)
+	}
+	c.sessionRefreshed(ctx, event)
+	return true
+}
+
+// resetCounters starts the call count and the streaks of r over for a
+// new session.
+func (r *route) resetCounters() {
+	r.calls.Store(0)
+	r.unauthorizedStreak.Store(0)
+	r.rateLimitedStreak.Store(0)
+}
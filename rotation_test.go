@@ -0,0 +1,216 @@
+package yahoofinanceapi_test
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	yahoofinanceapi "github.com/oscarli916/yahoo-finance-api"
+	"github.com/oscarli916/yahoo-finance-api/yahoofinancetest"
+)
+
+// rotations records the events passed to the rotation hooks.
+type rotations struct {
+	mu            sync.Mutex
+	before, after []yahoofinanceapi.RotationEvent
+}
+
+func (r *rotations) option() yahoofinanceapi.ClientOption {
+	return yahoofinanceapi.WithRotationHooks(
+		func(e yahoofinanceapi.RotationEvent) {
+			r.mu.Lock()
+			r.before = append(r.before, e)
+			r.mu.Unlock()
+		},
+		func(e yahoofinanceapi.RotationEvent) {
+			r.mu.Lock()
+			r.after = append(r.after, e)
+			r.mu.Unlock()
+		},
+	)
+}
+
+// reasons returns the reasons of the rotations, checking that both hooks
+// saw the same events.
+func (r *rotations) reasons(t *testing.T) []yahoofinanceapi.RotationReason {
+	t.Helper()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.before) != len(r.after) {
+		t.Fatalf("before hook ran %d times, after hook %d times", len(r.before), len(r.after))
+	}
+	var reasons []yahoofinanceapi.RotationReason
+	for i, e := range r.before {
+		if e != r.after[i] {
+			t.Errorf("hooks got %+v and %+v, want the same event", e, r.after[i])
+		}
+		reasons = append(reasons, e.Reason)
+	}
+	return reasons
+}
+
+// forbiddingTransport answers the next forbid quote requests with a plain
+// 403 and counts the bootstrap requests it passes on.
+type forbiddingTransport struct {
+	bootstrapCounter
+	forbid atomic.Int64
+}
+
+func (f *forbiddingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Path == "/v7/finance/quote" && f.forbid.Add(-1) >= 0 {
+		return &http.Response{
+			StatusCode: http.StatusForbidden,
+			Header:     http.Header{"Content-Type": {"text/plain"}},
+			Body:       io.NopCloser(strings.NewReader("Forbidden")),
+			Request:    req,
+		}, nil
+	}
+	return f.bootstrapCounter.RoundTrip(req)
+}
+
+func rotationClient(server *yahoofinancetest.Server, policy yahoofinanceapi.RotationPolicy, opts ...yahoofinanceapi.ClientOption) (*yahoofinanceapi.Client, *forbiddingTransport, *rotations) {
+	transport := &forbiddingTransport{}
+	hooks := &rotations{}
+	opts = append([]yahoofinanceapi.ClientOption{
+		yahoofinanceapi.WithTransport(transport),
+		yahoofinanceapi.WithRotationPolicy(policy),
+		yahoofinanceapi.WithRetryPolicy(fastRetries),
+		yahoofinanceapi.WithLogger(nil),
+		hooks.option(),
+	}, opts...)
+	return server.NewClient(opts...), transport, hooks
+}
+
+func quoteServer() *yahoofinancetest.Server {
+	server := yahoofinancetest.NewServer()
+	server.SetQuote(yahoofinanceapi.StockQuote{Symbol: "AAPL", RegularMarketPrice: 190})
+	return server
+}
+
+func TestRotationCountsCalls(t *testing.T) {
+	server := quoteServer()
+	defer server.Close()
+	client, transport, hooks := rotationClient(server, yahoofinanceapi.RotationPolicy{EveryNCalls: 3})
+	quote := yahoofinanceapi.NewQuoteWithClient(client)
+
+	// A rejected session is replaced and replayed within the call, and
+	// the new session starts counting from zero.
+	getQuotesThrough(t, quote, 1)
+	server.RevokeSession()
+	getQuotesThrough(t, quote, 1)
+	// Retries belong to the call they retry.
+	server.Fail(yahoofinancetest.FailServerError, 2)
+	getQuotesThrough(t, quote, 2)
+	if got := hooks.reasons(t); len(got) != 0 {
+		t.Fatalf("rotated for %v before the third call on the new session", got)
+	}
+	getQuotesThrough(t, quote, 1)
+	if got := hooks.reasons(t); len(got) != 1 || got[0] != yahoofinanceapi.RotationCallCount {
+		t.Fatalf("rotations = %v, want one for the call count", got)
+	}
+	if e := hooks.before[0]; e.Calls != 3 || e.Route != "direct" || e.SessionID == "" {
+		t.Errorf("event = %+v, want 3 calls on the direct route", e)
+	}
+	if n := transport.cookies.Load(); n != 3 {
+		t.Errorf("bootstrapped %d sessions, want 3", n)
+	}
+}
+
+func TestRotationOnUnauthorizedStreak(t *testing.T) {
+	server := quoteServer()
+	defer server.Close()
+	client, transport, hooks := rotationClient(server, yahoofinanceapi.RotationPolicy{UnauthorizedStreak: 2})
+	quote := yahoofinanceapi.NewQuoteWithClient(client)
+
+	getQuotesThrough(t, quote, 1)
+	// A success in between breaks the streak.
+	for _, forbid := range []int64{1, 0, 1, 1} {
+		transport.forbid.Store(forbid)
+		quote.GetQuote("AAPL")
+	}
+	if got := hooks.reasons(t); len(got) != 1 || got[0] != yahoofinanceapi.RotationUnauthorized {
+		t.Fatalf("rotations = %v, want one after two 403s in a row", got)
+	}
+	getQuotesThrough(t, quote, 1)
+	if n := transport.cookies.Load(); n != 2 {
+		t.Errorf("bootstrapped %d sessions, want 2", n)
+	}
+}
+
+func TestRotationOnRateLimitedStreak(t *testing.T) {
+	server := quoteServer()
+	defer server.Close()
+	client, transport, hooks := rotationClient(server, yahoofinanceapi.RotationPolicy{RateLimitedStreak: 3})
+	quote := yahoofinanceapi.NewQuoteWithClient(client)
+
+	getQuotesThrough(t, quote, 1)
+	server.Fail(yahoofinancetest.FailRateLimit, 2)
+	getQuotesThrough(t, quote, 1)
+	if got := hooks.reasons(t); len(got) != 0 {
+		t.Fatalf("rotated for %v after two 429s", got)
+	}
+	server.Fail(yahoofinancetest.FailRateLimit, 3)
+	if _, err := quote.GetQuote("AAPL"); err == nil {
+		t.Fatal("GetQuote succeeded, want every attempt to be rate limited")
+	}
+	if got := hooks.reasons(t); len(got) != 1 || got[0] != yahoofinanceapi.RotationRateLimited {
+		t.Fatalf("rotations = %v, want one after three 429s in a row", got)
+	}
+	getQuotesThrough(t, quote, 1)
+	if n := transport.cookies.Load(); n != 2 {
+		t.Errorf("bootstrapped %d sessions, want 2", n)
+	}
+}
+
+func TestRotationOnAge(t *testing.T) {
+	server := quoteServer()
+	defer server.Close()
+	client, transport, hooks := rotationClient(server, yahoofinanceapi.RotationPolicy{MaxAge: 30 * time.Millisecond})
+	quote := yahoofinanceapi.NewQuoteWithClient(client)
+
+	getQuotesThrough(t, quote, 2)
+	time.Sleep(40 * time.Millisecond)
+	getQuotesThrough(t, quote, 2)
+	if got := hooks.reasons(t); len(got) != 1 || got[0] != yahoofinanceapi.RotationAge {
+		t.Fatalf("rotations = %v, want one for the session age", got)
+	}
+	if e := hooks.before[0]; e.Age < 30*time.Millisecond || e.Calls != 3 {
+		t.Errorf("event = %+v, want an age of at least 30ms and 3 calls", e)
+	}
+	if n := transport.cookies.Load(); n != 2 {
+		t.Errorf("bootstrapped %d sessions, want 2", n)
+	}
+}
+
+func TestRotateSession(t *testing.T) {
+	server := quoteServer()
+	defer server.Close()
+	client, transport, hooks := rotationClient(server, yahoofinanceapi.RotationPolicy{})
+	quote := yahoofinanceapi.NewQuoteWithClient(client)
+
+	// Without a session there is nothing to rotate.
+	client.RotateSession()
+	getQuotesThrough(t, quote, 1)
+
+	// Concurrent rotations of the same session run the hooks once.
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			client.RotateSession()
+		}()
+	}
+	wg.Wait()
+	if got := hooks.reasons(t); len(got) != 1 || got[0] != yahoofinanceapi.RotationManual {
+		t.Fatalf("rotations = %v, want one manual rotation", got)
+	}
+	getQuotesThrough(t, quote, 1)
+	if n := transport.cookies.Load(); n != 2 {
+		t.Errorf("bootstrapped %d sessions, want 2", n)
+	}
+}
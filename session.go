@@ -0,0 +1,139 @@
+package yahoofinanceapi
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// session is one cookie/crumb pair. It is never modified after it has
+// been published by a sessionManager, so readers may share it freely.
+type session struct {
+	id      string
+	cookies []*http.Cookie
+	crumb   string
+	profile HeaderProfile
+	created time.Time
+}
+
+func newSessionID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// sessionManager hands out the current session and makes sure only one
+// bootstrap runs at a time; concurrent callers wait for its result.
+type sessionManager struct {
+	bootstrap func(ctx context.Context) (*session, error)
+
+	mu       sync.RWMutex
+	current  *session
+	inflight *refreshCall
+}
+
+type refreshCall struct {
+	done chan struct{}
+	s    *session
+	err  error
+}
+
+func newSessionManager(bootstrap func(ctx context.Context) (*session, error)) *sessionManager {
+	return &sessionManager{bootstrap: bootstrap}
+}
+
+// get returns the current session, bootstrapping one if needed.
+func (m *sessionManager) get(ctx context.Context) (*session, error) {
+	m.mu.RLock()
+	s := m.current
+	m.mu.RUnlock()
+	if s != nil {
+		return s, nil
+	}
+	return m.refresh(ctx)
+}
+
+func (m *sessionManager) refresh(ctx context.Context) (*session, error) {
+	for {
+		m.mu.Lock()
+		if m.current != nil {
+			s := m.current
+			m.mu.Unlock()
+			return s, nil
+		}
+		call := m.inflight
+		leader := call == nil
+		if leader {
+			call = &refreshCall{done: make(chan struct{})}
+			m.inflight = call
+		}
+		m.mu.Unlock()
+
+		if leader {
+			call.s, call.err = m.bootstrap(ctx)
+			m.mu.Lock()
+			if call.err == nil {
+				m.current = call.s
+			}
+			m.inflight = nil
+			m.mu.Unlock()
+			close(call.done)
+			return call.s, call.err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-call.done:
+		}
+		// The leader's own context may have been cancelled; that is not
+		// our failure, so try again and possibly lead the next bootstrap.
+		if errors.Is(call.err, context.Canceled) || errors.Is(call.err, context.DeadlineExceeded) {
+			continue
+		}
+		return call.s, call.err
+	}
+}
+
+// invalidate drops s if it is still the current session and reports
+// whether it did. Passing a session that was already replaced is a
+// no-op, so several callers that saw the same broken session trigger only
+// one refresh.
+func (m *sessionManager) invalidate(s *session) bool {
+	if s == nil {
+		return false
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.current != s {
+		return false
+	}
+	m.current = nil
+	return true
+}
+
+// isSessionRejected reports whether resp is Yahoo's answer to a stale
+// crumb or cookie, e.g.
+//
+//	{"finance":{"result":null,"error":{"code":"Unauthorized","description":"Invalid Crumb"}}}
+func isSessionRejected(resp *http.Response) bool {
+	if resp.StatusCode != http.StatusUnauthorized {
+		return false
+	}
+	body := strings.ToLower(string(bodyBytes(resp)))
+	return strings.Contains(body, "invalid crumb") ||
+		strings.Contains(body, "invalid cookie") ||
+		strings.Contains(body, `"unauthorized"`)
+}
+
+// peek returns the current session without bootstrapping one.
+func (m *sessionManager) peek() *session {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.current
+}
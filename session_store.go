@@ -0,0 +1,172 @@
+package yahoofinanceapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// DefaultSessionMaxAge is how long a stored session is reused before a
+// new cookie/crumb handshake is made.
+const DefaultSessionMaxAge = 24 * time.Hour
+
+// StoredSession is the persisted form of a cookie/crumb session.
+type StoredSession struct {
+	ID        string         `json:"id"`
+	Cookies   []*http.Cookie `json:"cookies"`
+	Crumb     string         `json:"crumb"`
+	Profile   *HeaderProfile `json:"profile,omitempty"`
+	CreatedAt time.Time      `json:"createdAt"`
+}
+
+// SessionStore persists the session of a Client so that it survives
+// process restarts. Load returns nil and no error when nothing is stored.
+type SessionStore interface {
+	Load(ctx context.Context) (*StoredSession, error)
+	Save(ctx context.Context, s *StoredSession) error
+	Clear(ctx context.Context) error
+}
+
+// WithSessionStore makes the client reuse the session kept in store and
+// save every new session to it.
+func WithSessionStore(store SessionStore) ClientOption {
+	return func(c *Client) {
+		c.sessionStore = store
+	}
+}
+
+// WithSessionMaxAge changes DefaultSessionMaxAge for this client.
+func WithSessionMaxAge(d time.Duration) ClientOption {
+	return func(c *Client) {
+		c.sessionMaxAge = d
+	}
+}
+
+// FileSessionStore keeps the session as JSON in a single file, which is
+// written with owner-only permissions since it holds cookies.
+type FileSessionStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileSessionStore returns a store backed by the file at path. The
+// file and its directory are created on the first Save.
+func NewFileSessionStore(path string) *FileSessionStore {
+	return &FileSessionStore{path: path}
+}
+
+func (f *FileSessionStore) Load(ctx context.Context) (*StoredSession, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	data, err := os.ReadFile(f.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var s StoredSession
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (f *FileSessionStore) Save(ctx context.Context, s *StoredSession) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(f.path), 0o700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), filepath.Base(f.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), f.path)
+}
+
+func (f *FileSessionStore) Clear(ctx context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := os.Remove(f.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// loadStoredSession returns the stored session if there is one that is
+// still usable.
+func (c *Client) loadStoredSession(ctx context.Context, r *route) *session {
+	if c.sessionStore == nil || !r.persistent {
+		return nil
+	}
+	stored, err := c.sessionStore.Load(ctx)
+	if err != nil {
+		c.log().WarnContext(ctx, "Failed to load stored Yahoo Finance session", "err", err)
+		return nil
+	}
+	if stored == nil || !validCrumb(stored.Crumb) {
+		return nil
+	}
+	now := time.Now()
+	if c.sessionMaxAge > 0 && now.Sub(stored.CreatedAt) > c.sessionMaxAge {
+		c.clearStoredSession(ctx, r)
+		return nil
+	}
+	for _, cookie := range stored.Cookies {
+		if !cookie.Expires.IsZero() && cookie.Expires.Before(now) {
+			c.clearStoredSession(ctx, r)
+			return nil
+		}
+	}
+	s := &session{id: stored.ID, cookies: stored.Cookies, crumb: stored.Crumb, created: stored.CreatedAt}
+	if s.id == "" {
+		s.id = newSessionID()
+	}
+	// The crumb is tied to the browser that obtained it, so keep using it.
+	if stored.Profile != nil {
+		s.profile = *stored.Profile
+	} else {
+		s.profile = c.pickHeaderProfile()
+	}
+	return s
+}
+
+func (c *Client) saveSession(ctx context.Context, r *route, s *session) {
+	if c.sessionStore == nil || !r.persistent {
+		return
+	}
+	profile := s.profile
+	stored := &StoredSession{ID: s.id, Cookies: s.cookies, Crumb: s.crumb, Profile: &profile, CreatedAt: s.created}
+	if err := c.sessionStore.Save(ctx, stored); err != nil {
+		c.log().WarnContext(ctx, "Failed to save Yahoo Finance session", "err", err)
+	}
+}
+
+// clearStoredSession discards the stored session after it expired or
+// was rejected, so that no later process picks it up again.
+func (c *Client) clearStoredSession(ctx context.Context, r *route) {
+	if c.sessionStore == nil || !r.persistent {
+		return
+	}
+	if err := c.sessionStore.Clear(ctx); err != nil {
+		c.log().WarnContext(ctx, "Failed to clear stored Yahoo Finance session", "err", err)
+	}
+}
@@ -0,0 +1,129 @@
+package yahoofinanceapi_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	yahoofinanceapi "github.com/oscarli916/yahoo-finance-api"
+	"github.com/oscarli916/yahoo-finance-api/yahoofinancetest"
+)
+
+func TestFileSessionStore(t *testing.T) {
+	server := yahoofinancetest.NewServer()
+	defer server.Close()
+	server.SetQuote(yahoofinanceapi.StockQuote{Symbol: "AAPL", RegularMarketPrice: 190})
+	path := filepath.Join(t.TempDir(), "session", "yahoo.json")
+
+	first := server.NewClient(yahoofinanceapi.WithSessionStore(yahoofinanceapi.NewFileSessionStore(path)))
+	if _, err := yahoofinanceapi.NewQuoteWithClient(first).GetQuote("AAPL"); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := info.Mode().Perm(); mode != 0o600 {
+		t.Errorf("session file mode = %v, want 0600", mode)
+	}
+
+	// A new client, as after a restart, reuses the stored session.
+	counter := &bootstrapCounter{}
+	store := yahoofinanceapi.NewFileSessionStore(path)
+	second := server.NewClient(
+		yahoofinanceapi.WithSessionStore(store),
+		yahoofinanceapi.WithTransport(counter),
+		yahoofinanceapi.WithLogger(nil),
+	)
+	if _, err := yahoofinanceapi.NewQuoteWithClient(second).GetQuote("AAPL"); err != nil {
+		t.Fatal(err)
+	}
+	if cookies, crumbs := counter.cookies.Load(), counter.crumbs.Load(); cookies != 0 || crumbs != 0 {
+		t.Errorf("bootstrapped %d cookies and %d crumbs, want the stored session", cookies, crumbs)
+	}
+
+	// Once Yahoo rejects it, the stored session is replaced.
+	before, err := store.Load(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.RevokeSession()
+	if _, err := yahoofinanceapi.NewQuoteWithClient(second).GetQuote("AAPL"); err != nil {
+		t.Fatal(err)
+	}
+	after, err := store.Load(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after == nil || after.Crumb == before.Crumb || after.ID == before.ID {
+		t.Errorf("stored session after revocation = %+v, want a new one", after)
+	}
+}
+
+func TestSessionMaxAge(t *testing.T) {
+	server := yahoofinancetest.NewServer()
+	defer server.Close()
+	server.SetQuote(yahoofinanceapi.StockQuote{Symbol: "AAPL", RegularMarketPrice: 190})
+	path := filepath.Join(t.TempDir(), "yahoo.json")
+
+	first := server.NewClient(yahoofinanceapi.WithSessionStore(yahoofinanceapi.NewFileSessionStore(path)))
+	if _, err := yahoofinanceapi.NewQuoteWithClient(first).GetQuote("AAPL"); err != nil {
+		t.Fatal(err)
+	}
+
+	counter := &bootstrapCounter{}
+	second := server.NewClient(
+		yahoofinanceapi.WithSessionStore(yahoofinanceapi.NewFileSessionStore(path)),
+		yahoofinanceapi.WithSessionMaxAge(time.Nanosecond),
+		yahoofinanceapi.WithTransport(counter),
+	)
+	if _, err := yahoofinanceapi.NewQuoteWithClient(second).GetQuote("AAPL"); err != nil {
+		t.Fatal(err)
+	}
+	if n := counter.crumbs.Load(); n != 1 {
+		t.Errorf("fetched %d crumbs, want the expired session to be replaced", n)
+	}
+}
+
+func TestStoredCookiesExpire(t *testing.T) {
+	server := yahoofinancetest.NewServer()
+	defer server.Close()
+	server.SetQuote(yahoofinanceapi.StockQuote{Symbol: "AAPL", RegularMarketPrice: 190})
+	store := yahoofinanceapi.NewFileSessionStore(filepath.Join(t.TempDir(), "yahoo.json"))
+
+	first := server.NewClient(yahoofinanceapi.WithSessionStore(store))
+	if _, err := yahoofinanceapi.NewQuoteWithClient(first).GetQuote("AAPL"); err != nil {
+		t.Fatal(err)
+	}
+	stored, err := store.Load(context.Background())
+	if err != nil || stored == nil || len(stored.Cookies) != 1 {
+		t.Fatalf("stored session = %+v, %v, want one cookie", stored, err)
+	}
+	// The server issues its cookies for a year.
+	if until := time.Until(stored.Cookies[0].Expires); until < 364*24*time.Hour {
+		t.Fatalf("stored cookie expires in %v, want the expiry of its Set-Cookie", until)
+	}
+
+	// A stored session whose cookie ran out is bootstrapped again.
+	stored.Cookies[0].Expires = time.Now().Add(-time.Minute)
+	if err := store.Save(context.Background(), stored); err != nil {
+		t.Fatal(err)
+	}
+	counter := &bootstrapCounter{}
+	second := server.NewClient(
+		yahoofinanceapi.WithSessionStore(store),
+		yahoofinanceapi.WithTransport(counter),
+		yahoofinanceapi.WithLogger(nil),
+	)
+	if _, err := yahoofinanceapi.NewQuoteWithClient(second).GetQuote("AAPL"); err != nil {
+		t.Fatal(err)
+	}
+	if n := counter.cookies.Load(); n != 1 {
+		t.Errorf("fetched %d cookies, want the expired session to be replaced", n)
+	}
+	if replaced, err := store.Load(context.Background()); err != nil || replaced == nil || !replaced.Cookies[0].Expires.After(time.Now()) {
+		t.Errorf("stored session after the refresh = %+v, %v, want a live cookie", replaced, err)
+	}
+}
@@ -0,0 +1,108 @@
+package yahoofinanceapi_test
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	yahoofinanceapi "github.com/oscarli916/yahoo-finance-api"
+	"github.com/oscarli916/yahoo-finance-api/yahoofinancetest"
+)
+
+// bootstrapCounter counts the cookie and crumb requests it passes on.
+type bootstrapCounter struct {
+	cookies, crumbs atomic.Int64
+}
+
+func (b *bootstrapCounter) RoundTrip(req *http.Request) (*http.Response, error) {
+	switch req.URL.Path {
+	case "/cookie":
+		b.cookies.Add(1)
+	case "/v1/test/getcrumb":
+		b.crumbs.Add(1)
+	}
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// getQuotes makes n concurrent GetQuote calls and returns how many failed.
+func getQuotes(quote *yahoofinanceapi.Quote, n int) int64 {
+	var wg sync.WaitGroup
+	var failed atomic.Int64
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if q, err := quote.GetQuote("AAPL"); err != nil || q.RegularMarketPrice != 190 {
+				failed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	return failed.Load()
+}
+
+func TestConcurrentSessionBootstrap(t *testing.T) {
+	server := yahoofinancetest.NewServer()
+	defer server.Close()
+	server.SetQuote(yahoofinanceapi.StockQuote{Symbol: "AAPL", RegularMarketPrice: 190})
+	counter := &bootstrapCounter{}
+	client := server.NewClient(
+		yahoofinanceapi.WithTransport(counter),
+		yahoofinanceapi.WithCoalescing(false),
+		yahoofinanceapi.WithLogger(nil),
+	)
+	quote := yahoofinanceapi.NewQuoteWithClient(client)
+
+	if n := getQuotes(quote, 300); n != 0 {
+		t.Fatalf("%d of 300 calls failed", n)
+	}
+	if cookies, crumbs := counter.cookies.Load(), counter.crumbs.Load(); cookies != 1 || crumbs != 1 {
+		t.Errorf("bootstrapped %d cookies and %d crumbs, want 1 each", cookies, crumbs)
+	}
+
+	// Every call holding the revoked session is rejected, but the session
+	// is refreshed only once.
+	server.RevokeSession()
+	if n := getQuotes(quote, 300); n != 0 {
+		t.Fatalf("%d of 300 calls failed after the session was revoked", n)
+	}
+	if cookies, crumbs := counter.cookies.Load(), counter.crumbs.Load(); cookies != 2 || crumbs != 2 {
+		t.Errorf("bootstrapped %d cookies and %d crumbs, want 2 each", cookies, crumbs)
+	}
+	if n := client.ForcedRefreshes(); n != 1 {
+		t.Errorf("ForcedRefreshes() = %d, want 1", n)
+	}
+}
+
+func TestInvalidCrumbRefreshesSession(t *testing.T) {
+	server := yahoofinancetest.NewServer()
+	defer server.Close()
+	server.SetQuote(yahoofinanceapi.StockQuote{Symbol: "AAPL", RegularMarketPrice: 190})
+	counter := &bootstrapCounter{}
+	client := server.NewClient(yahoofinanceapi.WithTransport(counter), yahoofinanceapi.WithLogger(nil))
+	quote := yahoofinanceapi.NewQuoteWithClient(client)
+
+	server.Fail(yahoofinancetest.FailInvalidCrumb, 1)
+	if _, err := quote.GetQuote("AAPL"); err != nil {
+		t.Fatal(err)
+	}
+	if n := client.ForcedRefreshes(); n != 1 {
+		t.Errorf("ForcedRefreshes() = %d, want 1", n)
+	}
+	if n := counter.crumbs.Load(); n != 2 {
+		t.Errorf("fetched %d crumbs, want 2", n)
+	}
+
+	// A session that keeps being rejected is refreshed once per call and
+	// then reported as unauthorized.
+	server.Fail(yahoofinancetest.FailInvalidCrumb, -1)
+	_, err := quote.GetQuote("AAPL")
+	if !errors.Is(err, yahoofinanceapi.ErrUnauthorized) {
+		t.Fatalf("GetQuote error = %v, want ErrUnauthorized", err)
+	}
+	if n := client.ForcedRefreshes(); n != 2 {
+		t.Errorf("ForcedRefreshes() = %d, want 2", n)
+	}
+}
@@ -1,9 +1,6 @@
 package yahoofinanceapi
 
-import (
-	"fmt"
-	"log"
-)
+import "fmt"
 
 func TestVolume() {
 	fmt.Println("=== Volume 테스트 프로그램 ===")
@@ -26,7 +23,7 @@ func TestVolume() {
 	// 데이터 조회
 	history, err := ticker.History(query)
 	if err != nil {
-		log.Printf("Error: %v", err)
+		fmt.Printf("Error: %v\n", err)
 		return
 	}
 
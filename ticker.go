@@ -1,5 +1,7 @@
 package yahoofinanceapi
 
+import "context"
+
 /*
  * Ticker Module
  *
@@ -13,13 +15,20 @@ package yahoofinanceapi
 
 type Ticker struct {
 	Symbol  string
+	client  *Client
 	history *History
 	option  *Option
 	quote   *Quote
 }
 
 func NewTicker(symbol string) *Ticker {
-	return &Ticker{Symbol: symbol}
+	return NewTickerWithClient(symbol, GetClient())
+}
+
+// NewTickerWithClient는 지정한 Client를 사용하는 Ticker를 생성합니다.
+// History, Option, Quote는 모두 이 Client를 공유합니다.
+func NewTickerWithClient(symbol string, client *Client) *Ticker {
+	return &Ticker{Symbol: symbol, client: client}
 }
 
 // History는 주식의 과거 가격 데이터를 조회합니다.
@@ -31,11 +40,16 @@ func NewTicker(symbol string) *Ticker {
 // - map[string]PriceData: 날짜별 가격 데이터
 // - error: 조회 중 발생한 오류
 func (t *Ticker) History(query HistoryQuery) (map[string]PriceData, error) {
+	return t.HistoryContext(context.Background(), query)
+}
+
+// HistoryContext는 ctx를 사용하여 History를 수행합니다.
+func (t *Ticker) HistoryContext(ctx context.Context, query HistoryQuery) (map[string]PriceData, error) {
 	if t.history == nil {
-		t.history = NewHistory()
+		t.history = NewHistoryWithClient(t.client)
 	}
 	t.history.SetQuery(query)
-	history, err := t.history.GetHistory(t.Symbol)
+	history, err := t.history.GetHistoryContext(ctx, t.Symbol)
 	if err != nil {
 		return nil, err
 	}
@@ -56,28 +70,34 @@ func (t *Ticker) History(query HistoryQuery) (map[string]PriceData, error) {
 // - 이는 실제 거래량이 적거나 Yahoo Finance API의 제한사항일 수 있습니다
 // - 가격 데이터(OHLC)는 정상적으로 제공되지만 volume은 제한적일 수 있습니다
 func (t *Ticker) HistoryWithPremarket(query HistoryQuery) (map[string]PriceData, error) {
-	if t.history == nil {
-		t.history = NewHistory()
-	}
+	return t.HistoryWithPremarketContext(context.Background(), query)
+}
+
+// HistoryWithPremarketContext는 ctx를 사용하여 HistoryWithPremarket을 수행합니다.
+func (t *Ticker) HistoryWithPremarketContext(ctx context.Context, query HistoryQuery) (map[string]PriceData, error) {
 	// premarket 데이터를 포함하도록 설정
 	query.Prepost = true
-	t.history.SetQuery(query)
-	history, err := t.history.GetHistory(t.Symbol)
-	if err != nil {
-		return nil, err
-	}
-	return t.history.transformData(history), nil
+	return t.HistoryContext(ctx, query)
 }
 
 // OptionChain은 주식의 옵션 체인 정보를 조회합니다.
 //
 // 반환값:
 // - OptionData: 옵션 체인 데이터
-func (t *Ticker) OptionChain() OptionData {
+// - error: 조회 중 발생한 오류 (옵션이 없는 심볼이면 ErrNoOptions)
+func (t *Ticker) OptionChain() (OptionData, error) {
+	return t.OptionChainContext(context.Background())
+}
+
+// OptionChainContext는 ctx를 사용하여 OptionChain을 수행합니다.
+func (t *Ticker) OptionChainContext(ctx context.Context) (OptionData, error) {
 	if t.option == nil {
-		t.option = NewOption()
+		t.option = NewOptionWithClient(t.client)
+	}
+	optionChain, err := t.option.GetOptionChainContext(ctx, t.Symbol)
+	if err != nil {
+		return OptionData{}, err
 	}
-	optionChain := t.option.GetOptionChain(t.Symbol)
 	return t.option.transformData(optionChain)
 }
 
@@ -88,11 +108,20 @@ func (t *Ticker) OptionChain() OptionData {
 //
 // 반환값:
 // - OptionData: 해당 만료일의 옵션 체인 데이터
-func (t *Ticker) OptionChainByExpiration(expiration string) OptionData {
+// - error: 조회 중 발생한 오류 (만료일이 목록에 없으면 ErrExpirationNotAvailable)
+func (t *Ticker) OptionChainByExpiration(expiration string) (OptionData, error) {
+	return t.OptionChainByExpirationContext(context.Background(), expiration)
+}
+
+// OptionChainByExpirationContext는 ctx를 사용하여 OptionChainByExpiration을 수행합니다.
+func (t *Ticker) OptionChainByExpirationContext(ctx context.Context, expiration string) (OptionData, error) {
 	if t.option == nil {
-		t.option = NewOption()
+		t.option = NewOptionWithClient(t.client)
+	}
+	optionChain, err := t.option.GetOptionChainByExpirationContext(ctx, t.Symbol, expiration)
+	if err != nil {
+		return OptionData{}, err
 	}
-	optionChain := t.option.GetOptionChainByExpiration(t.Symbol, expiration)
 	return t.option.transformData(optionChain)
 }
 
@@ -100,12 +129,17 @@ func (t *Ticker) OptionChainByExpiration(expiration string) OptionData {
 //
 // 반환값:
 // - []string: 만료일 목록
-func (t *Ticker) ExpirationDates() []string {
+// - error: 조회 중 발생한 오류 (옵션이 없는 심볼이면 ErrNoOptions)
+func (t *Ticker) ExpirationDates() ([]string, error) {
+	return t.ExpirationDatesContext(context.Background())
+}
+
+// ExpirationDatesContext는 ctx를 사용하여 ExpirationDates를 수행합니다.
+func (t *Ticker) ExpirationDatesContext(ctx context.Context) ([]string, error) {
 	if t.option == nil {
-		t.option = NewOption()
+		t.option = NewOptionWithClient(t.client)
 	}
-	expirationDates := t.option.GetExpirationDates(t.Symbol)
-	return expirationDates
+	return t.option.GetExpirationDatesContext(ctx, t.Symbol)
 }
 
 // Quote는 주식의 실시간 quote 정보를 조회합니다.
@@ -114,10 +148,15 @@ func (t *Ticker) ExpirationDates() []string {
 // - StockQuote: 실시간 quote 정보
 // - error: 조회 중 발생한 오류
 func (t *Ticker) Quote() (StockQuote, error) {
+	return t.QuoteContext(context.Background())
+}
+
+// QuoteContext는 ctx를 사용하여 Quote를 수행합니다.
+func (t *Ticker) QuoteContext(ctx context.Context) (StockQuote, error) {
 	if t.quote == nil {
-		t.quote = NewQuote()
+		t.quote = NewQuoteWithClient(t.client)
 	}
-	return t.quote.GetQuote(t.Symbol)
+	return t.quote.GetQuoteContext(ctx, t.Symbol)
 }
 
 // MarketCap은 주식의 시가총액 정보를 조회합니다.
@@ -126,8 +165,13 @@ func (t *Ticker) Quote() (StockQuote, error) {
 // - MarketCapInfo: 시가총액 관련 정보
 // - error: 조회 중 발생한 오류
 func (t *Ticker) MarketCap() (MarketCapInfo, error) {
+	return t.MarketCapContext(context.Background())
+}
+
+// MarketCapContext는 ctx를 사용하여 MarketCap을 수행합니다.
+func (t *Ticker) MarketCapContext(ctx context.Context) (MarketCapInfo, error) {
 	if t.quote == nil {
-		t.quote = NewQuote()
+		t.quote = NewQuoteWithClient(t.client)
 	}
-	return t.quote.GetMarketCap(t.Symbol)
+	return t.quote.GetMarketCapContext(ctx, t.Symbol)
 }
@@ -0,0 +1,301 @@
+// Package yahoofinancetest helps testing code built on yahoofinanceapi
+// without talking to Yahoo Finance.
+package yahoofinancetest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// noInteraction starts the error description of the response a replaying
+// Recorder sends for a request missing from its fixture file.
+const noInteraction = "yahoofinancetest: no recorded interaction"
+
+// VolatileParams are query parameters ignored when matching requests
+// against recorded interactions.
+var VolatileParams = []string{"crumb", "_"}
+
+// RangeParams are ignored when matching a request that also carries a
+// "range" parameter. Yahoo answers such requests from the range alone,
+// and GetHistory fills period2 in from the current time.
+var RangeParams = []string{"period1", "period2"}
+
+// redacted replaces cookie values in recorded Set-Cookie headers.
+const redacted = "REDACTED"
+
+// Mode tells a Recorder whether to capture or replay traffic.
+type Mode int
+
+const (
+	// ModeReplay answers requests from the fixture file only.
+	ModeReplay Mode = iota
+	// ModeRecord sends requests upstream and records every exchange.
+	ModeRecord
+	// ModeReplayOrRecord replays known requests and records the others.
+	ModeReplayOrRecord
+)
+
+// Interaction is one recorded request/response exchange.
+type Interaction struct {
+	Method string `json:"method"`
+	// URL is the request URL without the crumb and other volatile
+	// parameters.
+	URL     string      `json:"url"`
+	Status  int         `json:"status"`
+	Header  http.Header `json:"header,omitempty"`
+	Body    string      `json:"body"`
+	matched string
+}
+
+// Recorder is an http.RoundTripper that records exchanges with Yahoo
+// Finance to a fixture file and replays them later. Plug it into a client
+// with yahoofinanceapi.WithTransport.
+//
+// Requests are matched on method, path and query parameters, with
+// VolatileParams and IgnoreParams left out, and RangeParams too when the
+// request has a range. The host is not compared, so fixtures recorded
+// against query2 also answer query1 or a local test server. Identical
+// requests are answered in the order they were recorded; once those run
+// out the last one is repeated.
+//
+// Cookie values are redacted before they are recorded, so fixture files
+// can be committed without leaking a live session.
+//
+// A replaying Recorder answers a request missing from the fixture file
+// with a 501 Not Implemented response carrying a Yahoo error envelope
+// whose description reads "yahoofinancetest: no recorded interaction for
+// <request>". The client does not retry a 501 and fails the call with a
+// *yahoofinanceapi.APIError holding that description.
+type Recorder struct {
+	// Transport sends requests upstream while recording. Defaults to
+	// http.DefaultTransport.
+	Transport http.RoundTripper
+	// IgnoreParams are additional query parameters left out of matching,
+	// for example "period2" when it is derived from the current time.
+	IgnoreParams []string
+
+	path string
+	mode Mode
+
+	mu           sync.Mutex
+	interactions []*Interaction
+	next         map[string]int
+	dirty        bool
+}
+
+// NewRecorder returns a Recorder backed by the fixture file at path. The
+// file must exist in ModeReplay; in the other modes a missing file starts
+// an empty recording.
+func NewRecorder(path string, mode Mode) (*Recorder, error) {
+	r := &Recorder{path: path, mode: mode, next: make(map[string]int)}
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist) && mode != ModeReplay:
+		return r, nil
+	case err != nil:
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &r.interactions); err != nil {
+		return nil, fmt.Errorf("yahoofinancetest: parse %s: %w", path, err)
+	}
+	return r, nil
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	key := r.key(req.Method, req.URL)
+	if r.mode != ModeRecord {
+		if in := r.lookup(key); in != nil {
+			return in.response(req), nil
+		}
+		if r.mode == ModeReplay {
+			return missing(req, key), nil
+		}
+	}
+
+	transport := r.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	in := &Interaction{
+		Method:  req.Method,
+		URL:     r.clean(req.URL).String(),
+		Status:  resp.StatusCode,
+		Header:  redactCookies(resp.Header),
+		Body:    string(body),
+		matched: key,
+	}
+	r.mu.Lock()
+	r.interactions = append(r.interactions, in)
+	r.dirty = true
+	r.mu.Unlock()
+	return resp, nil
+}
+
+// Interactions returns a copy of the recorded interactions.
+func (r *Recorder) Interactions() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]Interaction, len(r.interactions))
+	for i, in := range r.interactions {
+		out[i] = *in
+	}
+	return out
+}
+
+// Save writes the interactions to the fixture file if anything new was
+// recorded.
+func (r *Recorder) Save() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.dirty {
+		return nil
+	}
+	data, err := json.MarshalIndent(r.interactions, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(r.path, append(data, '\n'), 0o644); err != nil {
+		return err
+	}
+	r.dirty = false
+	return nil
+}
+
+// lookup returns the next interaction recorded for key, or nil.
+func (r *Recorder) lookup(key string) *Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var matches []*Interaction
+	for _, in := range r.interactions {
+		if in.matched == "" {
+			u, err := url.Parse(in.URL)
+			if err != nil {
+				continue
+			}
+			in.matched = r.key(in.Method, u)
+		}
+		if in.matched == key {
+			matches = append(matches, in)
+		}
+	}
+	if len(matches) == 0 {
+		return nil
+	}
+	i := r.next[key]
+	if i < len(matches)-1 {
+		r.next[key] = i + 1
+	} else {
+		i = len(matches) - 1
+	}
+	return matches[i]
+}
+
+// key is the string requests are matched on.
+func (r *Recorder) key(method string, u *url.URL) string {
+	return strings.ToUpper(method) + " " + r.clean(u).RequestURI()
+}
+
+// clean returns u without fragment, user info and ignored parameters,
+// with the remaining parameters sorted.
+func (r *Recorder) clean(u *url.URL) *url.URL {
+	query := u.Query()
+	for _, name := range VolatileParams {
+		query.Del(name)
+	}
+	for _, name := range r.IgnoreParams {
+		query.Del(name)
+	}
+	if query.Has("range") {
+		for _, name := range RangeParams {
+			query.Del(name)
+		}
+	}
+	cleaned := &url.URL{Scheme: u.Scheme, Host: u.Host, Path: u.Path}
+	if len(query) > 0 {
+		for _, values := range query {
+			sort.Strings(values)
+		}
+		cleaned.RawQuery = query.Encode()
+	}
+	return cleaned
+}
+
+// response builds the recorded response for req.
+func (in *Interaction) response(req *http.Request) *http.Response {
+	header := in.Header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", in.Status, http.StatusText(in.Status)),
+		StatusCode:    in.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(in.Body)),
+		ContentLength: int64(len(in.Body)),
+		Request:       req,
+	}
+}
+
+// missing builds the 501 response a replaying Recorder answers an unknown
+// request with. The body is a Yahoo error envelope, so the client reports
+// it as an APIError carrying the noInteraction message.
+func missing(req *http.Request, key string) *http.Response {
+	body, _ := json.Marshal(map[string]any{
+		"finance": map[string]any{
+			"result": nil,
+			"error": map[string]string{
+				"code":        "Not Implemented",
+				"description": fmt.Sprintf("%s for %s", noInteraction, key),
+			},
+		},
+	})
+	in := &Interaction{
+		Status: http.StatusNotImplemented,
+		Header: http.Header{"Content-Type": {"application/json"}},
+		Body:   string(body),
+	}
+	return in.response(req)
+}
+
+// redactCookies returns a copy of header with the values of its
+// Set-Cookie lines replaced. Cookie names and attributes are kept.
+func redactCookies(header http.Header) http.Header {
+	header = header.Clone()
+	for i, line := range header.Values("Set-Cookie") {
+		name, rest, _ := strings.Cut(line, "=")
+		attrs := ""
+		if j := strings.IndexByte(rest, ';'); j >= 0 {
+			attrs = rest[j:]
+		}
+		header["Set-Cookie"][i] = name + "=" + redacted + attrs
+	}
+	return header
+}
@@ -0,0 +1,129 @@
+package yahoofinancetest
+
+import (
+	"errors"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	yahoofinanceapi "github.com/oscarli916/yahoo-finance-api"
+)
+
+// countingTransport counts the requests it passes on.
+type countingTransport struct {
+	next http.RoundTripper
+	n    atomic.Int64
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.n.Add(1)
+	return t.next.RoundTrip(req)
+}
+
+func recordHistory(t *testing.T, path string) yahoofinanceapi.YahooHistoryRespose {
+	t.Helper()
+	server := NewServer()
+	defer server.Close()
+	server.SetBars("AAPL", []Bar{
+		{Time: time.Date(2024, 1, 2, 14, 30, 0, 0, time.UTC), Open: 187, High: 188, Low: 183, Close: 185, Volume: 82488700},
+		{Time: time.Date(2024, 1, 3, 14, 30, 0, 0, time.UTC), Open: 184, High: 185, Low: 182, Close: 184, Volume: 58414500},
+	})
+
+	rec, err := NewRecorder(path, ModeRecord)
+	if err != nil {
+		t.Fatal(err)
+	}
+	history := yahoofinanceapi.NewHistoryWithClient(server.NewClient(yahoofinanceapi.WithTransport(rec)))
+	history.SetQuery(yahoofinanceapi.HistoryQuery{Range: "5d", Interval: "1d"})
+	want, err := history.GetHistory("AAPL")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rec.Save(); err != nil {
+		t.Fatal(err)
+	}
+	return want
+}
+
+func TestRecorderReplaysHistoryWithRange(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "aapl.json")
+	want := recordHistory(t, path)
+
+	rec, err := NewRecorder(path, ModeReplay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := yahoofinanceapi.NewClient(
+		yahoofinanceapi.WithBaseURL("http://replay.invalid"),
+		yahoofinanceapi.WithCookieURL("http://replay.invalid/cookie"),
+		yahoofinanceapi.WithTransport(rec),
+	)
+	history := yahoofinanceapi.NewHistoryWithClient(client)
+	// period2 defaults to the current time, so it never matches the recording.
+	end := strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10)
+	history.SetQuery(yahoofinanceapi.HistoryQuery{Range: "5d", Interval: "1d", End: end})
+	got, err := history.GetHistory("AAPL")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Chart.Result) != 1 || len(got.Chart.Result[0].Timestamp) != len(want.Chart.Result[0].Timestamp) {
+		t.Fatalf("replayed chart = %+v, want %+v", got.Chart.Result, want.Chart.Result)
+	}
+}
+
+func TestRecorderRedactsCookies(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "aapl.json")
+	recordHistory(t, path)
+
+	rec, err := NewRecorder(path, ModeReplay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cookies int
+	for _, in := range rec.Interactions() {
+		for _, line := range in.Header.Values("Set-Cookie") {
+			cookies++
+			if !strings.HasPrefix(line, "A3="+redacted+";") {
+				t.Errorf("recorded Set-Cookie %q, want a redacted A3 cookie", line)
+			}
+		}
+	}
+	if cookies == 0 {
+		t.Fatal("no Set-Cookie header recorded")
+	}
+}
+
+func TestRecorderMissIsNotRetried(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "aapl.json")
+	recordHistory(t, path)
+
+	rec, err := NewRecorder(path, ModeReplay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transport := &countingTransport{next: rec}
+	client := yahoofinanceapi.NewClient(
+		yahoofinanceapi.WithBaseURL("http://replay.invalid"),
+		yahoofinanceapi.WithCookieURL("http://replay.invalid/cookie"),
+		yahoofinanceapi.WithTransport(transport),
+	)
+	history := yahoofinanceapi.NewHistoryWithClient(client)
+	history.SetQuery(yahoofinanceapi.HistoryQuery{Range: "5d", Interval: "1d"})
+	_, err = history.GetHistory("MSFT")
+
+	var apiErr *yahoofinanceapi.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotImplemented {
+		t.Fatalf("GetHistory error = %v, want a 501 APIError", err)
+	}
+	if !strings.HasPrefix(apiErr.Description, noInteraction) {
+		t.Errorf("description = %q, want it to start with %q", apiErr.Description, noInteraction)
+	}
+	// Cookie, crumb and a single chart request.
+	if n := transport.n.Load(); n != 3 {
+		t.Errorf("sent %d requests, want 3", n)
+	}
+}
@@ -0,0 +1,543 @@
+package yahoofinancetest
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	yahoofinanceapi "github.com/oscarli916/yahoo-finance-api"
+)
+
+// Failure is a failure mode that Server can inject into its answers.
+type Failure int
+
+const (
+	// FailRateLimit answers API calls with 429 Too Many Requests.
+	FailRateLimit Failure = iota + 1
+	// FailServerError answers API calls with 503 Service Unavailable.
+	FailServerError
+	// FailInvalidCrumb answers API calls with Yahoo's 401 "Invalid Crumb"
+	// error, as if the session had been revoked.
+	FailInvalidCrumb
+	// FailNullOHLC replaces every open, high, low, close and volume value
+	// of chart answers with null.
+	FailNullOHLC
+	// FailMalformedTradingPeriods makes meta.tradingPeriods of chart
+	// answers unparseable. GetHistory still returns the chart and logs a
+	// warning through the client's logger; YahooMeta.GetTradingPeriods of
+	// the result is empty.
+	FailMalformedTradingPeriods
+)
+
+// Bar is one OHLCV data point of a chart.
+type Bar struct {
+	Time   time.Time
+	Open   float64
+	High   float64
+	Low    float64
+	Close  float64
+	Volume int64
+}
+
+// Request is an API call received by Server, without its crumb.
+type Request struct {
+	Method string
+	Path   string
+	Query  url.Values
+}
+
+// Server is a stand-in for Yahoo Finance serving the cookie, crumb,
+// chart, quote and options endpoints from in-memory fixtures.
+//
+// Cookies are issued at /cookie, a crumb is only handed out to requests
+// carrying that cookie, and API calls without the current crumb are
+// rejected with 401 "Invalid Crumb", like Yahoo does. RequireConsent puts
+// the EU consent form in front of the cookies.
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	cookie   string
+	crumb    string
+	charts   map[string]yahoofinanceapi.YahooHistoryResult
+	quotes   map[string]yahoofinanceapi.StockQuote
+	options  map[string]yahoofinanceapi.YahooOptionResult
+	failures map[Failure]int
+	requests []Request
+	consent  bool
+	consents map[string]string
+	accepted int
+}
+
+// NewServer starts a Server without fixtures. Call Close when done.
+func NewServer() *Server {
+	s := &Server{
+		cookie:   randomToken(),
+		crumb:    randomToken(),
+		charts:   make(map[string]yahoofinanceapi.YahooHistoryResult),
+		quotes:   make(map[string]yahoofinanceapi.StockQuote),
+		options:  make(map[string]yahoofinanceapi.YahooOptionResult),
+		failures: make(map[Failure]int),
+		consents: make(map[string]string),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/cookie", s.handleCookie)
+	mux.HandleFunc("/consent", s.handleConsent)
+	mux.HandleFunc("/consent/done", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, "<html><body>Thank you</body></html>")
+	})
+	mux.HandleFunc("/v1/test/getcrumb", s.handleCrumb)
+	mux.HandleFunc("/v8/finance/chart/", s.api(s.handleChart))
+	mux.HandleFunc("/v7/finance/quote", s.api(s.handleQuote))
+	mux.HandleFunc("/v7/finance/options/", s.api(s.handleOptions))
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// ClientOptions point a yahoofinanceapi.Client at the server.
+func (s *Server) ClientOptions() []yahoofinanceapi.ClientOption {
+	return []yahoofinanceapi.ClientOption{
+		yahoofinanceapi.WithBaseURL(s.URL),
+		yahoofinanceapi.WithCookieURL(s.URL + "/cookie"),
+	}
+}
+
+// NewClient returns a client talking to the server. opts are applied
+// after ClientOptions.
+func (s *Server) NewClient(opts ...yahoofinanceapi.ClientOption) *yahoofinanceapi.Client {
+	return yahoofinanceapi.NewClient(append(s.ClientOptions(), opts...)...)
+}
+
+// SetChart sets the chart returned for symbol. Timestamps outside the
+// period1/period2 parameters of a request are left out of the answer.
+func (s *Server) SetChart(symbol string, result yahoofinanceapi.YahooHistoryResult) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.charts[symbol] = result
+}
+
+// SetBars sets a chart for symbol built from bars, with a regular New
+// York trading period per bar.
+func (s *Server) SetBars(symbol string, bars []Bar) {
+	result := yahoofinanceapi.YahooHistoryResult{
+		Meta: yahoofinanceapi.YahooMeta{
+			Currency:             "USD",
+			Symbol:               symbol,
+			ExchangeName:         "NMS",
+			InstrumentType:       "EQUITY",
+			Timezone:             "EDT",
+			ExchangeTimezoneName: "America/New_York",
+			DataGranularity:      "1d",
+		},
+	}
+	quote := yahoofinanceapi.YahooQuote{}
+	var periods [][]yahoofinanceapi.YahooTradingPeriod
+	for _, bar := range bars {
+		result.Timestamp = append(result.Timestamp, bar.Time.Unix())
+		quote.Open = append(quote.Open, bar.Open)
+		quote.High = append(quote.High, bar.High)
+		quote.Low = append(quote.Low, bar.Low)
+		quote.Close = append(quote.Close, bar.Close)
+		quote.Volume = append(quote.Volume, bar.Volume)
+		periods = append(periods, []yahoofinanceapi.YahooTradingPeriod{{
+			Timezone: "EDT",
+			Start:    bar.Time.Unix(),
+			End:      bar.Time.Add(390 * time.Minute).Unix(),
+		}})
+	}
+	if n := len(bars); n > 0 {
+		last := bars[n-1]
+		result.Meta.RegularMarketPrice = last.Close
+		result.Meta.RegularMarketTime = last.Time.Unix()
+		result.Meta.RegularMarketVolume = last.Volume
+	}
+	result.Meta.TradingPeriods, _ = json.Marshal(periods)
+	result.Indicators.Quote = []yahoofinanceapi.YahooQuote{quote}
+	s.SetChart(symbol, result)
+}
+
+// SetQuote sets the quote returned for quote.Symbol.
+func (s *Server) SetQuote(quote yahoofinanceapi.StockQuote) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.quotes[quote.Symbol] = quote
+}
+
+// SetOptions sets the option chain of symbol. result.Options holds one
+// entry per expiration; ExpirationDates is filled in from it when empty.
+func (s *Server) SetOptions(symbol string, result yahoofinanceapi.YahooOptionResult) {
+	if result.UnderlyingSymbol == "" {
+		result.UnderlyingSymbol = symbol
+	}
+	if len(result.ExpirationDates) == 0 {
+		result.ExpirationDates = []int64{}
+		for _, o := range result.Options {
+			result.ExpirationDates = append(result.ExpirationDates, o.ExpirationDate)
+		}
+	}
+	if result.Strikes == nil {
+		result.Strikes = []float64{}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.options[symbol] = result
+}
+
+// SetNoOptions lists symbol without any options: answers carry empty
+// expirationDates, strikes and options, as Yahoo sends for a stock that
+// has no listed options, and the option calls fail with
+// yahoofinanceapi.ErrNoOptions. An unknown symbol is answered with an
+// empty result instead, which the client reports as ErrSymbolNotFound.
+func (s *Server) SetNoOptions(symbol string) {
+	s.SetOptions(symbol, yahoofinanceapi.YahooOptionResult{})
+}
+
+// Fail injects f into the next n API calls it applies to. A negative n
+// keeps failing until Recover is called.
+func (s *Server) Fail(f Failure, n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures[f] = n
+}
+
+// Recover removes every injected failure.
+func (s *Server) Recover() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = make(map[Failure]int)
+}
+
+// RevokeSession issues a new cookie and crumb, so that clients holding
+// the old ones are rejected until they bootstrap again.
+func (s *Server) RevokeSession() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cookie = randomToken()
+	s.crumb = randomToken()
+}
+
+// RequireConsent makes /cookie behave like Yahoo does for EU visitors:
+// it redirects to a consent page whose form carries csrfToken and
+// sessionId, and the A1 and A3 cookies are only issued once that form is
+// posted back with agree.
+func (s *Server) RequireConsent() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.consent = true
+}
+
+// ConsentsAccepted returns how many consent forms were accepted.
+func (s *Server) ConsentsAccepted() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.accepted
+}
+
+// Requests returns the API calls received so far, oldest first.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// failing consumes one injection of f and reports whether it applies.
+// The caller must hold s.mu.
+func (s *Server) failing(f Failure) bool {
+	n, ok := s.failures[f]
+	if !ok || n == 0 {
+		return false
+	}
+	if n > 0 {
+		s.failures[f] = n - 1
+	}
+	return true
+}
+
+func (s *Server) handleCookie(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	cookie, consent := s.cookie, s.consent
+	s.mu.Unlock()
+	if consent {
+		sessionID := randomToken()
+		s.mu.Lock()
+		s.consents[sessionID] = randomToken()
+		s.mu.Unlock()
+		http.Redirect(w, r, "/consent?sessionId="+sessionID, http.StatusFound)
+		return
+	}
+	http.SetCookie(w, &http.Cookie{Name: "A3", Value: cookie, Path: "/", Expires: cookieExpiry(), HttpOnly: true})
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusNotFound)
+	fmt.Fprint(w, "<html><body>Not Found</body></html>")
+}
+
+// cookieExpiry returns the expiry of a cookie issued now. Like Yahoo's
+// A1 and A3, the server's cookies last a year.
+func cookieExpiry() time.Time {
+	return time.Now().AddDate(1, 0, 0)
+}
+
+// handleConsent serves the consent form and issues the cookies once it
+// is accepted.
+func (s *Server) handleConsent(w http.ResponseWriter, r *http.Request) {
+	sessionID := r.URL.Query().Get("sessionId")
+	s.mu.Lock()
+	csrfToken, ok := s.consents[sessionID]
+	s.mu.Unlock()
+	if !ok {
+		http.Error(w, "unknown consent session", http.StatusBadRequest)
+		return
+	}
+
+	if r.Method == http.MethodPost {
+		if err := r.ParseForm(); err != nil ||
+			r.PostForm.Get("agree") != "agree" ||
+			r.PostForm.Get("csrfToken") != csrfToken ||
+			r.PostForm.Get("sessionId") != sessionID {
+			http.Error(w, "consent not accepted", http.StatusForbidden)
+			return
+		}
+		s.mu.Lock()
+		delete(s.consents, sessionID)
+		s.accepted++
+		cookie := s.cookie
+		s.mu.Unlock()
+		http.SetCookie(w, &http.Cookie{Name: "A1", Value: randomToken(), Path: "/", Expires: cookieExpiry(), HttpOnly: true})
+		http.SetCookie(w, &http.Cookie{Name: "A3", Value: cookie, Path: "/", Expires: cookieExpiry(), HttpOnly: true})
+		http.Redirect(w, r, "/consent/done", http.StatusFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprintf(w, `<html><body><form method="post" class="consent-form" action="">
+<input type="hidden" name="csrfToken" value="%s">
+<input type="hidden" name="sessionId" value="%s">
+<button type="submit" name="agree" value="agree">Accept all</button>
+</form></body></html>`, csrfToken, sessionID)
+}
+
+func (s *Server) handleCrumb(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	cookie, crumb := s.cookie, s.crumb
+	s.mu.Unlock()
+	if c, err := r.Cookie("A3"); err != nil || c.Value != cookie {
+		writeJSON(w, http.StatusUnauthorized, map[string]any{
+			"finance": envelope("Unauthorized", "User is unable to access this feature"),
+		})
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain;charset=utf-8")
+	fmt.Fprint(w, crumb)
+}
+
+// api wraps an API handler with request logging, crumb checks and the
+// failures that apply to every API endpoint.
+func (s *Server) api(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		crumb := query.Get("crumb")
+		query.Del("crumb")
+
+		s.mu.Lock()
+		s.requests = append(s.requests, Request{Method: r.Method, Path: r.URL.Path, Query: query})
+		valid := crumb == s.crumb
+		rateLimited := s.failing(FailRateLimit)
+		serverError := !rateLimited && s.failing(FailServerError)
+		invalidCrumb := !rateLimited && !serverError && s.failing(FailInvalidCrumb)
+		s.mu.Unlock()
+
+		switch {
+		case rateLimited:
+			w.Header().Set("Content-Type", "text/plain")
+			w.WriteHeader(http.StatusTooManyRequests)
+			fmt.Fprint(w, "Too Many Requests")
+		case serverError:
+			w.Header().Set("Content-Type", "text/plain")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprint(w, "Service Unavailable")
+		case invalidCrumb || !valid:
+			writeJSON(w, http.StatusUnauthorized, map[string]any{
+				"finance": envelope("Unauthorized", "Invalid Crumb"),
+			})
+		default:
+			next(w, r)
+		}
+	}
+}
+
+func (s *Server) handleChart(w http.ResponseWriter, r *http.Request) {
+	symbol := strings.TrimPrefix(r.URL.Path, "/v8/finance/chart/")
+	s.mu.Lock()
+	result, ok := s.charts[symbol]
+	nullOHLC := ok && s.failing(FailNullOHLC)
+	malformed := ok && s.failing(FailMalformedTradingPeriods)
+	s.mu.Unlock()
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]any{
+			"chart": envelope("Not Found", "No data found, symbol may be delisted"),
+		})
+		return
+	}
+
+	result = slicePeriod(result, r.URL.Query())
+	var body any = yahoofinanceapi.YahooHistoryRespose{
+		Chart: yahoofinanceapi.YahooChart{Result: []yahoofinanceapi.YahooHistoryResult{result}},
+	}
+	if nullOHLC || malformed {
+		body = breakChart(body, len(result.Timestamp), nullOHLC, malformed)
+	}
+	writeJSON(w, http.StatusOK, body)
+}
+
+func (s *Server) handleQuote(w http.ResponseWriter, r *http.Request) {
+	result := []yahoofinanceapi.StockQuote{}
+	s.mu.Lock()
+	for _, symbol := range strings.Split(r.URL.Query().Get("symbols"), ",") {
+		if quote, ok := s.quotes[strings.TrimSpace(symbol)]; ok {
+			result = append(result, quote)
+		}
+	}
+	s.mu.Unlock()
+	writeJSON(w, http.StatusOK, yahoofinanceapi.QuoteResponse{
+		QuoteResponse: yahoofinanceapi.QuoteResponseData{Result: result},
+	})
+}
+
+func (s *Server) handleOptions(w http.ResponseWriter, r *http.Request) {
+	symbol := strings.TrimPrefix(r.URL.Path, "/v7/finance/options/")
+	s.mu.Lock()
+	result, ok := s.options[symbol]
+	s.mu.Unlock()
+	if !ok {
+		writeJSON(w, http.StatusOK, yahoofinanceapi.YahooOptionResponse{
+			OptionChain: yahoofinanceapi.YahooOptionChain{Result: []yahoofinanceapi.YahooOptionResult{}},
+		})
+		return
+	}
+
+	// Yahoo answers with the nearest expiration, or with the requested
+	// one and no chain at all when that date is not listed.
+	options := []yahoofinanceapi.YahooOptions{}
+	if date := r.URL.Query().Get("date"); date != "" {
+		for _, o := range result.Options {
+			if strconv.FormatInt(o.ExpirationDate, 10) == date {
+				options = append(options, o)
+			}
+		}
+	} else if len(result.Options) > 0 {
+		options = append(options, result.Options[0])
+	}
+	result.Options = options
+	writeJSON(w, http.StatusOK, yahoofinanceapi.YahooOptionResponse{
+		OptionChain: yahoofinanceapi.YahooOptionChain{Result: []yahoofinanceapi.YahooOptionResult{result}},
+	})
+}
+
+// slicePeriod drops the data points outside period1 and period2.
+func slicePeriod(result yahoofinanceapi.YahooHistoryResult, query url.Values) yahoofinanceapi.YahooHistoryResult {
+	from, err := strconv.ParseInt(query.Get("period1"), 10, 64)
+	if err != nil {
+		from = 0
+	}
+	to, err := strconv.ParseInt(query.Get("period2"), 10, 64)
+	if err != nil {
+		to = 1<<63 - 1
+	}
+
+	var keep []int
+	for i, ts := range result.Timestamp {
+		if ts >= from && ts <= to {
+			keep = append(keep, i)
+		}
+	}
+	if len(keep) == len(result.Timestamp) {
+		return result
+	}
+
+	timestamps := make([]int64, 0, len(keep))
+	for _, i := range keep {
+		timestamps = append(timestamps, result.Timestamp[i])
+	}
+	quotes := make([]yahoofinanceapi.YahooQuote, len(result.Indicators.Quote))
+	for q, quote := range result.Indicators.Quote {
+		for _, i := range keep {
+			if i < len(quote.Open) {
+				quotes[q].Open = append(quotes[q].Open, quote.Open[i])
+			}
+			if i < len(quote.High) {
+				quotes[q].High = append(quotes[q].High, quote.High[i])
+			}
+			if i < len(quote.Low) {
+				quotes[q].Low = append(quotes[q].Low, quote.Low[i])
+			}
+			if i < len(quote.Close) {
+				quotes[q].Close = append(quotes[q].Close, quote.Close[i])
+			}
+			if i < len(quote.Volume) {
+				quotes[q].Volume = append(quotes[q].Volume, quote.Volume[i])
+			}
+		}
+	}
+	result.Timestamp = timestamps
+	result.Indicators.Quote = quotes
+	return result
+}
+
+// breakChart rewrites a chart answer with null OHLC values and/or
+// malformed trading periods.
+func breakChart(body any, points int, nullOHLC, malformed bool) any {
+	data, _ := json.Marshal(body)
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return body
+	}
+	chart, _ := doc["chart"].(map[string]any)
+	results, _ := chart["result"].([]any)
+	for _, r := range results {
+		result, _ := r.(map[string]any)
+		if malformed {
+			if meta, ok := result["meta"].(map[string]any); ok {
+				meta["tradingPeriods"] = []any{[]any{map[string]any{"start": "n/a", "end": "n/a"}}}
+			}
+		}
+		if nullOHLC {
+			indicators, _ := result["indicators"].(map[string]any)
+			quotes, _ := indicators["quote"].([]any)
+			for _, q := range quotes {
+				quote, _ := q.(map[string]any)
+				for _, field := range []string{"open", "high", "low", "close", "volume"} {
+					quote[field] = make([]any, points)
+				}
+			}
+		}
+	}
+	return doc
+}
+
+func envelope(code, description string) map[string]any {
+	return map[string]any{
+		"result": nil,
+		"error":  map[string]string{"code": code, "description": description},
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json;charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func randomToken() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,94 @@
+package yahoofinancetest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	yahoofinanceapi "github.com/oscarli916/yahoo-finance-api"
+)
+
+func TestServerNoOptions(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	server.SetNoOptions("BRK-A")
+	option := yahoofinanceapi.NewOptionWithClient(server.NewClient())
+
+	if _, err := option.GetOptionChain("BRK-A"); !errors.Is(err, yahoofinanceapi.ErrNoOptions) {
+		t.Errorf("GetOptionChain error = %v, want ErrNoOptions", err)
+	}
+	if _, err := option.GetOptionChainByExpiration("BRK-A", "2024-01-19"); !errors.Is(err, yahoofinanceapi.ErrNoOptions) {
+		t.Errorf("GetOptionChainByExpiration error = %v, want ErrNoOptions", err)
+	}
+	if _, err := option.GetExpirationDates("BRK-A"); !errors.Is(err, yahoofinanceapi.ErrNoOptions) {
+		t.Errorf("GetExpirationDates error = %v, want ErrNoOptions", err)
+	}
+	if _, err := option.GetOptionChain("NOPE"); !errors.Is(err, yahoofinanceapi.ErrSymbolNotFound) {
+		t.Errorf("GetOptionChain of an unknown symbol error = %v, want ErrSymbolNotFound", err)
+	}
+}
+
+func TestServerMalformedTradingPeriods(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	server.SetBars("AAPL", []Bar{
+		{Time: time.Date(2024, 1, 2, 14, 30, 0, 0, time.UTC), Open: 187, High: 188, Low: 183, Close: 185, Volume: 82488700},
+	})
+	server.Fail(FailMalformedTradingPeriods, 1)
+
+	var logs bytes.Buffer
+	client := server.NewClient(yahoofinanceapi.WithLogger(slog.New(slog.NewTextHandler(&logs, nil))))
+	history := yahoofinanceapi.NewHistoryWithClient(client)
+	history.SetQuery(yahoofinanceapi.HistoryQuery{Range: "5d", Interval: "1d"})
+	got, err := history.GetHistory("AAPL")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Chart.Result) != 1 || len(got.Chart.Result[0].Timestamp) != 1 {
+		t.Fatalf("chart = %+v, want one bar", got.Chart.Result)
+	}
+	periods, err := got.Chart.Result[0].Meta.GetTradingPeriods()
+	if err != nil || len(periods) != 0 {
+		t.Errorf("GetTradingPeriods = %v, %v, want no periods and no error", periods, err)
+	}
+	if !strings.Contains(logs.String(), "level=WARN") || !strings.Contains(logs.String(), "tradingPeriods") {
+		t.Errorf("logs = %q, want a tradingPeriods warning", logs.String())
+	}
+}
+
+func TestServerConsent(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	server.RequireConsent()
+	server.SetQuote(yahoofinanceapi.StockQuote{Symbol: "AAPL", RegularMarketPrice: 190})
+
+	store := yahoofinanceapi.NewFileSessionStore(filepath.Join(t.TempDir(), "session.json"))
+	client := server.NewClient(yahoofinanceapi.WithSessionStore(store))
+	quote, err := yahoofinanceapi.NewQuoteWithClient(client).GetQuote("AAPL")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if quote.RegularMarketPrice != 190 {
+		t.Errorf("price = %v, want 190", quote.RegularMarketPrice)
+	}
+	if n := server.ConsentsAccepted(); n != 1 {
+		t.Errorf("accepted %d consent forms, want 1", n)
+	}
+
+	session, err := store.Load(context.Background())
+	if err != nil || session == nil {
+		t.Fatalf("stored session = %v, %v", session, err)
+	}
+	names := make(map[string]bool)
+	for _, cookie := range session.Cookies {
+		names[cookie.Name] = true
+	}
+	if !names["A1"] || !names["A3"] {
+		t.Errorf("session cookies = %v, want A1 and A3", names)
+	}
+}